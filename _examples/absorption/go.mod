@@ -1,21 +0,0 @@
-module absorption
-
-go 1.17
-
-require (
-	github.com/soypat/go-decim v0.0.0-20220122181453-0dcca4e66bdc
-	gonum.org/v1/plot v0.10.0
-)
-
-require (
-	github.com/ajstarks/svgo v0.0.0-20210923152817-c3b6e2f0c527 // indirect
-	github.com/fogleman/gg v1.3.0 // indirect
-	github.com/go-fonts/liberation v0.2.0 // indirect
-	github.com/go-latex/latex v0.0.0-20210823091927-c0d11ff05a81 // indirect
-	github.com/go-pdf/fpdf v0.5.0 // indirect
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
-	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d // indirect
-	golang.org/x/text v0.3.6 // indirect
-)
-
-replace github.com/soypat/go-decim => ../../.
\ No newline at end of file
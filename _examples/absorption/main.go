@@ -1,9 +1,10 @@
+// Command absorption draws the absorption data in testdata before and after
+// decimation. Run it from its directory with go run .
 package main
 
 import (
-	"encoding/csv"
+	"log"
 	"os"
-	"strconv"
 
 	"github.com/soypat/go-decim"
 	"gonum.org/v1/plot"
@@ -17,41 +18,28 @@ const (
 )
 
 func main() {
-	fp, _ := os.Open("../../testdata/ch4.csv")
-	rd := csv.NewReader(fp)
-	rec, err := rd.ReadAll()
+	fp, err := os.Open("../../testdata/ch4.csv")
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	c := &CSVXYer{xField: 0, yField: 1, records: rec[1:]} // rec[1:] to skip header
-	s := decim.NewSampler(c, 1e-4)
-	pc := plot.New()
-	cplot, _ := plotter.NewLine(c)
-	pc.Add(cplot)
-	pc.Save(width, height, "original.png")
-
-	pn := plot.New()
-	nplot, _ := plotter.NewLine(s.XYer())
-	pn.Add(nplot)
-	pn.Save(width, height, "decimated.png")
-}
-
-type CSVXYer struct {
-	xField, yField int
-	records        [][]string
-}
-
-func (c *CSVXYer) XY(i int) (x, y float64) {
-	rec := c.records[i]
-	x, err := strconv.ParseFloat(rec[c.xField], 64)
+	defer fp.Close()
+	c, err := decim.ReadCSV(fp, ',', "1", "2")
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	y, err = strconv.ParseFloat(rec[c.yField], 64)
-	if err != nil {
-		panic(err)
+	s := decim.NewSampler(c, 1e-4)
+	for _, img := range []struct {
+		xy   plotter.XYer
+		path string
+	}{{c, "original.png"}, {s.XYer(), "decimated.png"}} {
+		p := plot.New()
+		l, err := plotter.NewLine(img.xy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p.Add(l)
+		if err := p.Save(width, height, img.path); err != nil {
+			log.Fatal(err)
+		}
 	}
-	return x, y
 }
-
-func (c *CSVXYer) Len() int { return len(c.records) }
@@ -1,21 +1,18 @@
 package decim
 
-import (
-	"testing"
-
-	"github.com/dgryski/go-lttb"
-)
+import "testing"
 
 func BenchmarkLTTB(b *testing.B) {
+	xydata := pointXYer(data)
 	for i := 0; i < b.N; i++ {
-		lttb.LTTB(data, 1000)
+		LTTB(xydata, 1000)
 	}
 }
 
 func BenchmarkRollingX(b *testing.B) {
 	b.StopTimer()
 	xydata := pointXYer(data)
-	outdata := make([]lttb.Point, xydata.Len())
+	outdata := make([]point, xydata.Len())
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
 		s := NewSampler(xydata, .575)
@@ -24,18 +21,20 @@ func BenchmarkRollingX(b *testing.B) {
 			if err != nil {
 				break
 			}
-			outdata[j] = lttb.Point{X: x, Y: y}
+			outdata[j] = point{X: x, Y: y}
 		}
 	}
 }
 
-type pointXYer []lttb.Point
+type point struct{ X, Y float64 }
+
+type pointXYer []point
 
 func (p pointXYer) XY(i int) (x, y float64) { return p[i].X, p[i].Y }
 
 func (p pointXYer) Len() int { return len(p) }
 
-var data = []lttb.Point{
+var data = []point{
 	{0, 29.357995947822218}, {1, 29.40932479606209}, {2, 29.28168582006162}, {3, 30.409965579108867}, {4, 30.7726859735917}, {5, 30.839942247539028}, {6, 30.760611642264667}, {7, 31.203663004229718}, {8, 31.38899603525572}, {9, 30.890299916955737},
 	{10, 30.467811944911556}, {11, 30.596837868069542}, {12, 30.59789593509767}, {13, 30.19693062465079}, {14, 29.89081330734553}, {15, 29.54668002901058}, {16, 29.54890739422219}, {17, 30.53743760171474}, {18, 30.74066032317061}, {19, 30.3774450601516},
 	{20, 30.095148889986568}, {21, 30.057979182917986}, {22, 30.364655421168525}, {23, 30.293450053773604}, {24, 30.14578230340987}, {25, 30.277772879951996}, {26, 30.3711931235659}, {27, 30.355932660992572}, {28, 29.994740831603046}, {29, 29.938012885023657},
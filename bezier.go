@@ -0,0 +1,247 @@
+package decim
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Bezier is a cubic Bézier curve segment. Index 0 holds the start
+// point, 1 and 2 the control points and 3 the end point.
+type Bezier struct {
+	X, Y [4]float64
+}
+
+// At evaluates the curve at parameter t in [0, 1].
+func (b Bezier) At(t float64) (x, y float64) {
+	p := b.at(t)
+	return p.x, p.y
+}
+
+func (b Bezier) at(t float64) vec2 {
+	s := 1 - t
+	b0, b1, b2, b3 := s*s*s, 3*s*s*t, 3*s*t*t, t*t*t
+	return vec2{
+		x: b0*b.X[0] + b1*b.X[1] + b2*b.X[2] + b3*b.X[3],
+		y: b0*b.Y[0] + b1*b.Y[1] + b2*b.Y[2] + b3*b.Y[3],
+	}
+}
+
+func (b Bezier) point(i int) vec2 { return vec2{b.X[i], b.Y[i]} }
+
+// FitBezier approximates xyer with a sequence of connected cubic Bézier
+// curves using Schneider's algorithm ("An Algorithm for Automatically
+// Fitting Digitized Curves", Graphics Gems, 1990). Every point lies within
+// tol of the curve in euclidean distance, so x and y should be scaled to
+// comparable units (e.g. plot coordinates) before fitting.
+func FitBezier(xyer XYer, tol float64) ([]Bezier, error) {
+	if xyer == nil {
+		panic("got nil xyer")
+	}
+	if !(tol > 0) {
+		return nil, errors.New("tolerance must be positive")
+	}
+	d := make([]vec2, 0, xyer.Len())
+	for i := 0; i < xyer.Len(); i++ {
+		x, y := xyer.XY(i)
+		if math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			return nil, errors.New("got infinity or NaN")
+		}
+		if len(d) > 0 && d[len(d)-1] == (vec2{x, y}) {
+			continue // Repeated points have no tangent.
+		}
+		d = append(d, vec2{x, y})
+	}
+	if len(d) < 2 {
+		return nil, errors.New("need at least 2 distinct points to fit")
+	}
+	f := bezierFitter{d: d, errSq: tol * tol}
+	tHat1 := d[1].sub(d[0]).unit()
+	tHat2 := d[len(d)-2].sub(d[len(d)-1]).unit()
+	f.fit(0, len(d)-1, tHat1, tHat2)
+	return f.curves, nil
+}
+
+type bezierFitter struct {
+	d      []vec2
+	errSq  float64
+	curves []Bezier
+}
+
+func (f *bezierFitter) fit(first, last int, tHat1, tHat2 vec2) {
+	const maxIterations = 4
+	d := f.d
+	if last-first == 1 {
+		dist := d[last].sub(d[first]).norm() / 3
+		f.curves = append(f.curves, newBezier(d[first], d[first].add(tHat1.scale(dist)), d[last].add(tHat2.scale(dist)), d[last]))
+		return
+	}
+	u := f.chordLengthParameterize(first, last)
+	bez := f.generate(first, last, u, tHat1, tHat2)
+	maxErr, split := f.maxError(first, last, bez, u)
+	if maxErr < f.errSq {
+		f.curves = append(f.curves, bez)
+		return
+	}
+	// If error is not too large try reparameterization and iteration.
+	if maxErr < 4*f.errSq {
+		for i := 0; i < maxIterations; i++ {
+			f.reparameterize(first, u, bez)
+			bez = f.generate(first, last, u, tHat1, tHat2)
+			maxErr, split = f.maxError(first, last, bez, u)
+			if maxErr < f.errSq {
+				f.curves = append(f.curves, bez)
+				return
+			}
+		}
+	}
+	// Fitting failed, split at point of maximum error and fit recursively.
+	tHatCenter := d[split-1].sub(d[split+1]).unit()
+	f.fit(first, split, tHat1, tHatCenter)
+	f.fit(split, last, tHatCenter.scale(-1), tHat2)
+}
+
+func newBezier(p0, p1, p2, p3 vec2) Bezier {
+	return Bezier{
+		X: [4]float64{p0.x, p1.x, p2.x, p3.x},
+		Y: [4]float64{p0.y, p1.y, p2.y, p3.y},
+	}
+}
+
+// generate finds the control points by least squares given fixed tangent directions.
+func (f *bezierFitter) generate(first, last int, u []float64, tHat1, tHat2 vec2) Bezier {
+	d := f.d
+	p0, p3 := d[first], d[last]
+	var c [2][2]float64
+	var xv [2]float64
+	for i, ui := range u {
+		s := 1 - ui
+		a1, a2 := tHat1.scale(3*s*s*ui), tHat2.scale(3*s*ui*ui)
+		c[0][0] += a1.dot(a1)
+		c[0][1] += a1.dot(a2)
+		c[1][1] += a2.dot(a2)
+		b0, b3 := s*s*s+3*s*s*ui, 3*s*ui*ui+ui*ui*ui
+		tmp := d[first+i].sub(p0.scale(b0).add(p3.scale(b3)))
+		xv[0] += a1.dot(tmp)
+		xv[1] += a2.dot(tmp)
+	}
+	c[1][0] = c[0][1]
+	detC := c[0][0]*c[1][1] - c[1][0]*c[0][1]
+	var alphaL, alphaR float64
+	if detC != 0 {
+		alphaL = (xv[0]*c[1][1] - c[0][1]*xv[1]) / detC
+		alphaR = (c[0][0]*xv[1] - xv[0]*c[1][0]) / detC
+	}
+	segLength := p3.sub(p0).norm()
+	if eps := 1e-6 * segLength; alphaL < eps || alphaR < eps {
+		// Fall back on Wu/Barsky heuristic.
+		alphaL, alphaR = segLength/3, segLength/3
+	}
+	return newBezier(p0, p0.add(tHat1.scale(alphaL)), p3.add(tHat2.scale(alphaR)), p3)
+}
+
+func (f *bezierFitter) chordLengthParameterize(first, last int) []float64 {
+	d := f.d
+	u := make([]float64, last-first+1)
+	for i := first + 1; i <= last; i++ {
+		u[i-first] = u[i-first-1] + d[i].sub(d[i-1]).norm()
+	}
+	total := u[len(u)-1]
+	for i := range u {
+		u[i] /= total
+	}
+	return u
+}
+
+// maxError returns the largest squared distance between points and
+// the curve at their parameters, and the index where it occurs.
+func (f *bezierFitter) maxError(first, last int, b Bezier, u []float64) (maxDist float64, split int) {
+	split = (last-first+1)/2 + first
+	for i := first + 1; i < last; i++ {
+		v := b.at(u[i-first]).sub(f.d[i])
+		if dist := v.dot(v); dist >= maxDist {
+			maxDist, split = dist, i
+		}
+	}
+	return maxDist, split
+}
+
+// reparameterize improves u in place with a Newton-Raphson step towards the closest curve point.
+func (f *bezierFitter) reparameterize(first int, u []float64, b Bezier) {
+	var q1 [3]vec2
+	var q2 [2]vec2
+	for i := 0; i < 3; i++ {
+		q1[i] = b.point(i + 1).sub(b.point(i)).scale(3)
+	}
+	for i := 0; i < 2; i++ {
+		q2[i] = q1[i+1].sub(q1[i]).scale(2)
+	}
+	for i := range u {
+		t := u[i]
+		s := 1 - t
+		qt := b.at(t)
+		q1t := q1[0].scale(s * s).add(q1[1].scale(2 * s * t)).add(q1[2].scale(t * t))
+		q2t := q2[0].scale(s).add(q2[1].scale(t))
+		diff := qt.sub(f.d[first+i])
+		den := q1t.dot(q1t) + diff.dot(q2t)
+		if den != 0 {
+			u[i] = t - diff.dot(q1t)/den
+		}
+	}
+}
+
+type vec2 struct{ x, y float64 }
+
+func (a vec2) add(b vec2) vec2      { return vec2{a.x + b.x, a.y + b.y} }
+func (a vec2) sub(b vec2) vec2      { return vec2{a.x - b.x, a.y - b.y} }
+func (a vec2) scale(f float64) vec2 { return vec2{a.x * f, a.y * f} }
+func (a vec2) dot(b vec2) float64   { return a.x*b.x + a.y*b.y }
+func (a vec2) norm() float64        { return math.Hypot(a.x, a.y) }
+func (a vec2) unit() vec2 {
+	n := a.norm()
+	if n == 0 {
+		return a
+	}
+	return a.scale(1 / n)
+}
+
+// WriteTikZ writes curves as a TikZ path using ".. controls .." syntax,
+// ready to be used in a \draw command. prec is the number of digits after
+// the decimal point since TikZ does not parse exponents, -1 uses the fewest
+// digits that represent values exactly.
+func WriteTikZ(w io.Writer, curves []Bezier, prec int) error {
+	bw := bufio.NewWriter(w)
+	for i, c := range curves {
+		if i == 0 || curves[i-1].point(3) != c.point(0) {
+			if i != 0 {
+				bw.WriteByte('\n')
+			}
+			writeTikZCoord(bw, prec, c.X[0], c.Y[0])
+		}
+		bw.WriteString("\n  .. controls ")
+		writeTikZCoord(bw, prec, c.X[1], c.Y[1])
+		bw.WriteString(" and ")
+		writeTikZCoord(bw, prec, c.X[2], c.Y[2])
+		bw.WriteString(" .. ")
+		writeTikZCoord(bw, prec, c.X[3], c.Y[3])
+	}
+	return bw.Flush()
+}
+
+func writeTikZCoord(w *bufio.Writer, prec int, x, y float64) {
+	w.WriteByte('(')
+	writeCoords(w, "", 'f', prec, x)
+	w.WriteByte(',')
+	writeCoords(w, "", 'f', prec, y)
+	w.WriteByte(')')
+}
+
+func writeCoords(w *bufio.Writer, sep string, fmt byte, prec int, v ...float64) {
+	var buf [32]byte
+	for _, f := range v {
+		w.WriteString(sep)
+		w.Write(strconv.AppendFloat(buf[:0], f, fmt, prec, 64))
+	}
+}
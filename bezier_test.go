@@ -0,0 +1,60 @@
+package decim
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFitBezier(t *testing.T) {
+	const n, tol = 2000, 1e-3
+	v := &sliceXYer{}
+	for i := 0; i < n; i++ {
+		x := 4 * math.Pi * float64(i) / n
+		v.x, v.y = append(v.x, x), append(v.y, math.Sin(x))
+	}
+	curves, err := FitBezier(v, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(curves) > n/50 {
+		t.Errorf("fit yielded too many curves: %d", len(curves))
+	}
+	for i, c := range curves {
+		if i > 0 && c.point(0) != curves[i-1].point(3) {
+			t.Fatalf("curve %d not connected to previous", i)
+		}
+	}
+	// Every data point must be close to some curve point.
+	for i := 0; i < n; i += 7 {
+		p := vec2{v.x[i], v.y[i]}
+		best := math.Inf(1)
+		for _, c := range curves {
+			if p.x < c.X[0]-tol || p.x > c.X[3]+tol {
+				continue
+			}
+			for k := 0; k <= 1000; k++ {
+				best = math.Min(best, c.at(float64(k)/1000).sub(p).norm())
+			}
+		}
+		if best > 2*tol {
+			t.Fatalf("point %d off curve by %g", i, best)
+		}
+	}
+	var svg, tikz bytes.Buffer
+	s := &SVG{Viewport: Viewport{XMin: 0, XMax: 4 * math.Pi, YMin: -1, YMax: 1, Width: 400, Height: 200}, Tolerance: tol}
+	if err := s.WriteCurves(&svg, curves); err != nil {
+		t.Fatal(err)
+	}
+	path := svg.String()[strings.Index(svg.String(), ` d="`)+4:]
+	if !strings.HasPrefix(path, "M0.0,100.0 C") || strings.Count(path, "C") != len(curves) || strings.Count(path, "M") != 1 {
+		t.Errorf("unexpected SVG path: %.60s", path)
+	}
+	if err := WriteTikZ(&tikz, curves, 4); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(tikz.String(), "(0.0000,0.0000)") || strings.Count(tikz.String(), ".. controls") != len(curves) {
+		t.Errorf("unexpected TikZ path: %.60s", tikz.String())
+	}
+}
@@ -0,0 +1,199 @@
+package decim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// BinaryFormat describes raw binary numerical data with no header.
+type BinaryFormat struct {
+	// Order is the byte order of values. Nil means little endian.
+	Order binary.ByteOrder
+	// Float32 selects 4 byte floating point values instead of 8 byte ones.
+	Float32 bool
+	// Columns is the number of values per row.
+	Columns int
+	// ColumnMajor is set when all values of the first column are stored
+	// before those of the second and so on. Otherwise rows are interleaved.
+	ColumnMajor bool
+}
+
+func (f BinaryFormat) decoder() numDecoder {
+	d := numDecoder{order: f.Order, kind: 'f', size: 8}
+	if d.order == nil {
+		d.order = binary.LittleEndian
+	}
+	if f.Float32 {
+		d.size = 4
+	}
+	return d
+}
+
+// BinaryReader reads rows of interleaved raw binary data.
+type BinaryReader struct {
+	r   *bufio.Reader
+	dec numDecoder
+	buf []byte
+}
+
+// NewBinaryReader returns a reader of rows of f.Columns values. f must not be column major.
+func NewBinaryReader(r io.Reader, f BinaryFormat) (*BinaryReader, error) {
+	if f.Columns < 1 {
+		return nil, errors.New("binary format needs at least one column")
+	}
+	if f.ColumnMajor {
+		return nil, errors.New("column major data can't be read by rows. use ReadBinary")
+	}
+	dec := f.decoder()
+	return &BinaryReader{r: bufio.NewReaderSize(r, 1<<16), dec: dec, buf: make([]byte, dec.size*f.Columns)}, nil
+}
+
+// Read reads the next row into dst, which must have room for all columns.
+// It returns io.EOF when no rows remain and io.ErrUnexpectedEOF if data
+// ends in the middle of a row.
+func (b *BinaryReader) Read(dst []float64) error {
+	if _, err := io.ReadFull(b.r, b.buf); err != nil {
+		return err
+	}
+	b.dec.decodeAll(dst[:len(b.buf)/b.dec.size], b.buf)
+	return nil
+}
+
+// ReadBinary reads all of r and returns the data's columns.
+func ReadBinary(r io.Reader, f BinaryFormat) ([][]float64, error) {
+	if f.Columns < 1 {
+		return nil, errors.New("binary format needs at least one column")
+	}
+	dec := f.decoder()
+	var values []float64
+	br := bufio.NewReaderSize(r, 1<<16)
+	buf := make([]byte, dec.size)
+	for {
+		_, err := io.ReadFull(br, buf)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		values = append(values, dec.decode(buf))
+	}
+	if len(values)%f.Columns != 0 {
+		return nil, fmt.Errorf("%d values do not fill %d columns", len(values), f.Columns)
+	}
+	rows := len(values) / f.Columns
+	cols := make([][]float64, f.Columns)
+	for j := range cols {
+		if f.ColumnMajor {
+			cols[j] = values[j*rows : (j+1)*rows : (j+1)*rows]
+			continue
+		}
+		cols[j] = make([]float64, rows)
+		for i := range cols[j] {
+			cols[j][i] = values[i*f.Columns+j]
+		}
+	}
+	return cols, nil
+}
+
+// WriteBinary writes columns of equal length to w in format f. f.Columns is ignored.
+func WriteBinary(w io.Writer, f BinaryFormat, columns ...[]float64) error {
+	f.Columns = len(columns)
+	if f.Columns == 0 {
+		return nil
+	}
+	rows := len(columns[0])
+	for _, c := range columns {
+		if len(c) != rows {
+			return errors.New("columns have different lengths")
+		}
+	}
+	enc := f.decoder()
+	bw := bufio.NewWriterSize(w, 1<<16)
+	buf := make([]byte, enc.size)
+	put := func(v float64) {
+		if enc.size == 4 {
+			enc.order.PutUint32(buf, math.Float32bits(float32(v)))
+		} else {
+			enc.order.PutUint64(buf, math.Float64bits(v))
+		}
+		bw.Write(buf)
+	}
+	if f.ColumnMajor {
+		for _, c := range columns {
+			for _, v := range c {
+				put(v)
+			}
+		}
+	} else {
+		for i := 0; i < rows; i++ {
+			for _, c := range columns {
+				put(c[i])
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// numDecoder converts binary encoded numbers to float64.
+type numDecoder struct {
+	order binary.ByteOrder
+	// kind is 'f' for floating point, 'i' for signed and
+	// 'u' for unsigned integers and 'b' for booleans.
+	kind byte
+	size int
+}
+
+func (d numDecoder) valid() bool {
+	switch d.kind {
+	case 'f':
+		return d.size == 4 || d.size == 8
+	case 'i', 'u':
+		return d.size == 1 || d.size == 2 || d.size == 4 || d.size == 8
+	case 'b':
+		return d.size == 1
+	}
+	return false
+}
+
+func (d numDecoder) decode(b []byte) float64 {
+	switch d.size {
+	case 1:
+		if d.kind == 'i' {
+			return float64(int8(b[0]))
+		}
+		return float64(b[0])
+	case 2:
+		v := d.order.Uint16(b)
+		if d.kind == 'i' {
+			return float64(int16(v))
+		}
+		return float64(v)
+	case 4:
+		v := d.order.Uint32(b)
+		switch d.kind {
+		case 'f':
+			return float64(math.Float32frombits(v))
+		case 'i':
+			return float64(int32(v))
+		}
+		return float64(v)
+	}
+	v := d.order.Uint64(b)
+	switch d.kind {
+	case 'f':
+		return math.Float64frombits(v)
+	case 'i':
+		return float64(int64(v))
+	}
+	return float64(v)
+}
+
+func (d numDecoder) decodeAll(dst []float64, b []byte) {
+	for i := range dst {
+		dst[i] = d.decode(b[i*d.size:])
+	}
+}
@@ -0,0 +1,117 @@
+package decim
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+// samplerStateVersion is the first byte of a Sampler's binary state.
+const samplerStateVersion = 1
+
+// samplerState is the JSON representation of a Sampler's state.
+type samplerState struct {
+	Index    int     `json:"index"`
+	Tol      float64 `json:"tol"`
+	XPivot   float64 `json:"x_pivot"`
+	YPivot   float64 `json:"y_pivot"`
+	XPrev    float64 `json:"x_prev"`
+	YPrev    float64 `json:"y_prev"`
+	AngleMin float64 `json:"angle_min"`
+	AngleMax float64 `json:"angle_max"`
+	Interp   bool    `json:"interp"`
+	Pivots   bool    `json:"pivots"`
+	Started  bool    `json:"started"`
+	Ended    bool    `json:"ended"`
+}
+
+// Bits of the flags byte of a Sampler's binary state.
+const (
+	stateInterp = 1 << iota
+	statePivots
+	stateStarted
+	stateEnded
+)
+
+// MarshalBinary encodes the sampler's progress so that a restarted process
+// may resume decimation where it stopped. The data being decimated
+// is not part of the state. Implements encoding.BinaryMarshaler.
+func (s *Sampler) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 2, 2+8*8)
+	b[0] = samplerStateVersion
+	for _, f := range [...]struct {
+		set bool
+		bit byte
+	}{{s.Interp, stateInterp}, {s.Pivots, statePivots}, {s.started, stateStarted}, {s.ended, stateEnded}} {
+		if f.set {
+			b[1] |= f.bit
+		}
+	}
+	b = appendUint64(b, uint64(s.idx))
+	for _, f := range [...]float64{s.tol, s.xPivot, s.yPivot, s.xPrev, s.yPrev, s.angleMin, s.angleMax} {
+		b = appendUint64(b, math.Float64bits(f))
+	}
+	return b, nil
+}
+
+// UnmarshalBinary restores state encoded by MarshalBinary. s must have been
+// created with NewSampler over the same data as the sampler that was
+// marshalled, after which calls to Next yield exactly the values the
+// original sampler would have. Implements encoding.BinaryUnmarshaler.
+func (s *Sampler) UnmarshalBinary(b []byte) error {
+	if len(b) != 2+8*8 {
+		return errors.New("bad sampler state length")
+	}
+	if b[0] != samplerStateVersion {
+		return errors.New("unsupported sampler state version")
+	}
+	u := func(i int) uint64 { return binary.LittleEndian.Uint64(b[2+8*i:]) }
+	f := func(i int) float64 { return math.Float64frombits(u(i)) }
+	st := samplerState{
+		Index: int(u(0)), Tol: f(1), XPivot: f(2), YPivot: f(3), XPrev: f(4), YPrev: f(5),
+		AngleMin: f(6), AngleMax: f(7), Interp: b[1]&stateInterp != 0,
+		Pivots: b[1]&statePivots != 0, Started: b[1]&stateStarted != 0, Ended: b[1]&stateEnded != 0,
+	}
+	return s.setState(st)
+}
+
+// MarshalJSON encodes the same state as MarshalBinary as a JSON object.
+func (s *Sampler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(samplerState{
+		Index: s.idx, Tol: s.tol,
+		XPivot: s.xPivot, YPivot: s.yPivot, XPrev: s.xPrev, YPrev: s.yPrev,
+		AngleMin: s.angleMin, AngleMax: s.angleMax, Interp: s.Interp,
+		Pivots: s.Pivots, Started: s.started, Ended: s.ended,
+	})
+}
+
+// UnmarshalJSON restores state encoded by MarshalJSON. As with
+// UnmarshalBinary, s must have been created with NewSampler.
+func (s *Sampler) UnmarshalJSON(b []byte) error {
+	var st samplerState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return err
+	}
+	return s.setState(st)
+}
+
+func (s *Sampler) setState(st samplerState) error {
+	if s.xyer == nil {
+		return errors.New("sampler has no data. create it with NewSampler before restoring state")
+	}
+	if st.Index < 1 || st.Index > s.xyer.Len() {
+		return errors.New("sampler state index out of data range")
+	}
+	s.idx, s.tol, s.Interp = st.Index, st.Tol, st.Interp
+	s.xPivot, s.yPivot, s.xPrev, s.yPrev = st.XPivot, st.YPivot, st.XPrev, st.YPrev
+	s.angleMin, s.angleMax = st.AngleMin, st.AngleMax
+	s.Pivots, s.started, s.ended = st.Pivots, st.Started, st.Ended
+	return nil
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
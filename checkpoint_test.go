@@ -0,0 +1,67 @@
+package decim
+
+import (
+	"encoding"
+	"encoding/json"
+	"testing"
+)
+
+func TestSamplerCheckpoint(t *testing.T) {
+	c := readTestCSV(t)
+	for _, mode := range []struct{ interp, pivots bool }{{false, false}, {true, false}, {false, true}} {
+		s := NewSampler(c, 1e-3)
+		s.Interp, s.Pivots = mode.interp, mode.pivots
+		want := s.XYer()
+
+		for _, codec := range []struct {
+			name      string
+			marshal   func(*Sampler) ([]byte, error)
+			unmarshal func(*Sampler, []byte) error
+		}{
+			{"binary", (*Sampler).MarshalBinary, (*Sampler).UnmarshalBinary},
+			{"json", func(s *Sampler) ([]byte, error) { return json.Marshal(s) }, func(s *Sampler, b []byte) error { return json.Unmarshal(b, s) }},
+		} {
+			s := NewSampler(c, 1e-3)
+			s.Interp, s.Pivots = mode.interp, mode.pivots
+			got := &sliceXYer{}
+			for i := 0; i < want.Len()/2; i++ {
+				x, y, err := s.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				got.x, got.y = append(got.x, x), append(got.y, y)
+			}
+			state, err := codec.marshal(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Resume in a new sampler as a restarted process would.
+			s = NewSampler(c, 5)
+			if err := codec.unmarshal(s, state); err != nil {
+				t.Fatal(err)
+			}
+			for {
+				x, y, err := s.Next()
+				if err != nil {
+					break
+				}
+				got.x, got.y = append(got.x, x), append(got.y, y)
+			}
+			if got.Len() != want.Len() {
+				t.Fatalf("%s %+v: resumed output has %d points, want %d", codec.name, mode, got.Len(), want.Len())
+			}
+			for i := range got.x {
+				if gx, gy := got.XY(i); gx != want.(*sliceXYer).x[i] || gy != want.(*sliceXYer).y[i] {
+					t.Fatalf("%s %+v: point %d differs after resume", codec.name, mode, i)
+				}
+			}
+		}
+	}
+}
+
+var (
+	_ encoding.BinaryMarshaler   = (*Sampler)(nil)
+	_ encoding.BinaryUnmarshaler = (*Sampler)(nil)
+	_ json.Marshaler             = (*Sampler)(nil)
+	_ json.Unmarshaler           = (*Sampler)(nil)
+)
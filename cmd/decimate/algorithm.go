@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	decim "github.com/soypat/go-decim"
+)
+
+// flags
+var algorithm string
+var algorithmPoints int
+var maxGap float64
+var deviation, doorWindow float64
+
+// algorithms are the downsampling algorithms chosen with --algorithm.
+var algorithms = []string{"rolling-x", "rolling-x-interp", "swinging-door", "rdp", "min-segments", "visvalingam", "lttb", "m4"}
+
+// checkAlgorithm checks the algorithm flags against the algorithm they
+// are parameters of. --interp is the same as rolling-x-interp.
+func checkAlgorithm() error {
+	if findStringInSlice(algorithm, algorithms) < 0 {
+		return fmt.Errorf("unknown --algorithm %q. want one of %s", algorithm, strings.Join(algorithms, ", "))
+	}
+	if interp && algorithm != "rolling-x" && algorithm != "rolling-x-interp" {
+		return fmt.Errorf("--interp selects rolling-x-interp and can't be used with --algorithm %s", algorithm)
+	}
+	countBased := algorithm == "lttb" || algorithm == "visvalingam" || algorithm == "m4"
+	if (countBased || algorithm == "rdp" || algorithm == "min-segments") && muxMode == muxWide {
+		// Columns are written one after the other once input ends so rows would not be in x order.
+		return fmt.Errorf("--algorithm %s writes each column once input ends and can't be used with --mux wide. use --mux tagged", algorithm)
+	}
+	switch {
+	case algorithm == "lttb" || algorithm == "visvalingam":
+		if algorithmPoints < 3 {
+			return fmt.Errorf("--algorithm %s needs --points of at least 3", algorithm)
+		}
+	case algorithmPoints != 0:
+		return errors.New("--points is a parameter of lttb and visvalingam")
+	}
+	if maxGap < 0 {
+		return errors.New("--max-gap must not be negative")
+	} else if maxGap > 0 && (algorithm != "rolling-x" || interp) {
+		return errors.New("--max-gap is a parameter of rolling-x")
+	}
+	if deviation < 0 || doorWindow < 0 {
+		return errors.New("--deviation and --window must not be negative")
+	} else if (deviation > 0 || doorWindow > 0) && algorithm != "swinging-door" {
+		return errors.New("--deviation and --window are parameters of swinging-door")
+	}
+	if algorithm == "m4" && widthPx < 1 {
+		return errors.New("--algorithm m4 needs the plot --width")
+	}
+	if countBased && columnToleranceFlag != "" {
+		return fmt.Errorf("--algorithm %s keeps a number of points and has no tolerance to set with --column-tolerance", algorithm)
+	}
+	return nil
+}
+
+// newStepper returns the stepper of the selected algorithm for a column
+// with tolerance tol. swinging-door uses half of tol as its deviation
+// unless --deviation is set so that data stays within tol.
+func newStepper(tol float64) stepper {
+	alg := algorithm
+	if interp && alg == "rolling-x" {
+		alg = "rolling-x-interp"
+	}
+	switch alg {
+	case "rolling-x-interp":
+		return interpStepper{tol: tol}
+	case "swinging-door":
+		dev := deviation
+		if dev == 0 {
+			dev = tol / 2
+		}
+		return swingingDoorStepper{dev: dev, window: doorWindow}
+	case "rdp":
+		return &bufferStepper{simplify: func(seg decim.XYer, _, _ float64) (decim.XYer, error) { return decim.RDP(seg, tol) }}
+	case "min-segments":
+		return &bufferStepper{simplify: func(seg decim.XYer, _, _ float64) (decim.XYer, error) { return decim.MinSegments(seg, tol) }}
+	case "visvalingam":
+		return &bufferStepper{simplify: func(seg decim.XYer, share, _ float64) (decim.XYer, error) {
+			return decim.Visvalingam(seg, segmentCount(algorithmPoints, share, 2))
+		}}
+	case "lttb":
+		return &bufferStepper{simplify: func(seg decim.XYer, share, _ float64) (decim.XYer, error) {
+			return decim.LTTB(seg, segmentCount(algorithmPoints, share, 3))
+		}}
+	case "m4":
+		return &bufferStepper{simplify: func(seg decim.XYer, _, span float64) (decim.XYer, error) {
+			return decim.M4(seg, segmentCount(int(widthPx), span, 1))
+		}}
+	}
+	return inPlaceStepper{tol: tol, maxDx: maxGap}
+}
+
+// segmentCount returns the part of n given by share to a segment of a
+// column split by gaps, at least min. Parts are rounded down so they only
+// add up to more than n when segments are given min.
+func segmentCount(n int, share float64, min int) int {
+	if c := int(math.Floor(float64(n) * share)); c > min {
+		return c
+	}
+	return min
+}
+
+// multiStepper is a stepper which may yield several points at once.
+type multiStepper interface {
+	stepper
+	// pending returns the points to be written after the last step.
+	pending() (xs, ys []float64, err error)
+}
+
+// bufferStepper runs an algorithm which needs the whole column. Points are
+// buffered until the NaN x which ends input. NaN y values split the column
+// into segments which are simplified on their own and written as is between
+// them to keep gaps. simplify is given the share of points and of the x range
+// of the column a segment holds to split count based parameters.
+type bufferStepper struct {
+	simplify func(seg decim.XYer, share, span float64) (decim.XYer, error)
+	buf      xySlices
+	// gaps holds the x of each NaN y and the number of points before it.
+	gaps []gap
+	out  xySlices
+	err  error
+}
+
+type gap struct {
+	x float64
+	i int
+}
+
+func (a *bufferStepper) step(x, y float64) stepper {
+	a.out.x, a.out.y = a.out.x[:0], a.out.y[:0]
+	switch {
+	case math.IsNaN(x):
+		a.err = a.flush()
+	case math.IsNaN(y):
+		a.gaps = append(a.gaps, gap{x: x, i: a.buf.Len()})
+	default:
+		a.buf.x, a.buf.y = append(a.buf.x, x), append(a.buf.y, y)
+	}
+	return a
+}
+
+// flush simplifies the buffered segments into out.
+func (a *bufferStepper) flush() error {
+	n := a.buf.Len()
+	if n == 0 {
+		for _, g := range a.gaps {
+			a.out.x, a.out.y = append(a.out.x, g.x), append(a.out.y, math.NaN())
+		}
+		a.gaps = a.gaps[:0]
+		return nil
+	}
+	span := a.buf.x[n-1] - a.buf.x[0]
+	start := 0
+	for k := 0; k <= len(a.gaps); k++ {
+		end := n
+		if k < len(a.gaps) {
+			end = a.gaps[k].i
+		}
+		if end > start {
+			seg := &xySlices{x: a.buf.x[start:end], y: a.buf.y[start:end]}
+			segSpan := 1.0
+			if span > 0 {
+				segSpan = (seg.x[len(seg.x)-1] - seg.x[0]) / span
+			}
+			v, err := a.simplify(seg, float64(end-start)/float64(n), segSpan)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < v.Len(); i++ {
+				vx, vy := v.XY(i)
+				a.out.x, a.out.y = append(a.out.x, vx), append(a.out.y, vy)
+			}
+		}
+		if k < len(a.gaps) {
+			a.out.x, a.out.y = append(a.out.x, a.gaps[k].x), append(a.out.y, math.NaN())
+		}
+		start = end
+	}
+	a.buf.x, a.buf.y, a.gaps = a.buf.x[:0], a.buf.y[:0], a.gaps[:0]
+	return nil
+}
+
+func (a *bufferStepper) pending() (xs, ys []float64, err error) {
+	return a.out.x, a.out.y, a.err
+}
+
+func (a *bufferStepper) xy() (x, y float64) { return math.NaN(), math.NaN() }
+
+func (a *bufferStepper) ready() bool { return false }
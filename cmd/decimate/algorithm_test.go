@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCheckAlgorithmMuxWide(t *testing.T) {
+	defer func(alg, mux string, points int, width float64) {
+		algorithm, muxMode, algorithmPoints, widthPx = alg, mux, points, width
+	}(algorithm, muxMode, algorithmPoints, widthPx)
+	algorithmPoints, widthPx = 0, 0
+	for _, alg := range []string{"rdp", "min-segments", "visvalingam", "lttb", "m4"} {
+		algorithm = alg
+		if alg == "visvalingam" || alg == "lttb" {
+			algorithmPoints = 100
+		} else if alg == "m4" {
+			algorithmPoints, widthPx = 0, 800
+		}
+		muxMode = muxTagged
+		if err := checkAlgorithm(); err != nil {
+			t.Errorf("%s with --mux tagged: %s", alg, err)
+		}
+		muxMode = muxWide
+		if err := checkAlgorithm(); err == nil {
+			t.Errorf("%s with --mux wide: expected error", alg)
+		}
+		algorithmPoints = 0
+	}
+	algorithm = "rolling-x"
+	if err := checkAlgorithm(); err != nil {
+		t.Errorf("rolling-x with --mux wide: %s", err)
+	}
+}
+
+func TestSegmentCount(t *testing.T) {
+	// Shares of four segments split by gaps.
+	shares := []float64{0.25, 0.25, 0.25, 0.25}
+	total := 0
+	for _, s := range shares {
+		total += segmentCount(10, s, 2)
+	}
+	if total > 10 {
+		t.Errorf("segments keep %d points, more than 10", total)
+	}
+	if c := segmentCount(3, shares[0], 3); c != 3 {
+		t.Errorf("got %d points for segment, want minimum of 3", c)
+	}
+}
+
+func TestMaxGap(t *testing.T) {
+	defer func(alg string, gap float64, in bool) { algorithm, maxGap, interp = alg, gap, in }(algorithm, maxGap, interp)
+	algorithm, maxGap, interp = "rolling-x", 10, false
+	if err := checkAlgorithm(); err != nil {
+		t.Fatal(err)
+	}
+	// A straight line is only written at its ends without --max-gap.
+	s := newStepper(0.1)
+	var xs []float64
+	for i := 0; i <= 100; i++ {
+		if s = s.step(float64(i), float64(i)); s.ready() {
+			x, _ := s.xy()
+			xs = append(xs, x)
+		}
+	}
+	for i := 1; i < len(xs); i++ {
+		if xs[i]-xs[i-1] > maxGap {
+			t.Errorf("points at x=%g and x=%g further apart than --max-gap", xs[i-1], xs[i])
+		}
+	}
+	if len(xs) < 100/10-1 {
+		t.Errorf("got %d points on a line of length 100 with --max-gap 10", len(xs))
+	}
+	for _, alg := range []string{"rolling-x-interp", "swinging-door", "rdp"} {
+		algorithm = alg
+		if err := checkAlgorithm(); err == nil {
+			t.Errorf("expected error for --max-gap with %s", alg)
+		}
+	}
+}
+
+func TestSwingingDoor(t *testing.T) {
+	defer func(alg string, dev, window float64) { algorithm, deviation, doorWindow = alg, dev, window }(algorithm, deviation, doorWindow)
+	const tol = 0.1
+	var xs, ys []float64
+	for i := 0; i <= 1000; i++ {
+		x := float64(i) / 100
+		xs, ys = append(xs, x), append(ys, math.Sin(x)+0.05*math.Sin(37*x))
+	}
+	// decimate steps s with the data and the NaN row ending input.
+	decimate := func(s stepper) (dx, dy []float64) {
+		for i := 0; i <= len(xs); i++ {
+			x, y := math.NaN(), math.NaN()
+			if i < len(xs) {
+				x, y = xs[i], ys[i]
+			}
+			if s = s.step(x, y); s.ready() {
+				x, y := s.xy()
+				dx, dy = append(dx, x), append(dy, y)
+			}
+		}
+		return dx, dy
+	}
+	algorithm = "rolling-x"
+	rx, _ := decimate(newStepper(tol))
+	algorithm = "swinging-door"
+	for _, window := range []float64{0, 0.5} {
+		deviation, doorWindow = 0, window
+		if err := checkAlgorithm(); err != nil {
+			t.Fatal(err)
+		}
+		dx, dy := decimate(newStepper(tol))
+		if window == 0 && len(dx) == len(rx) {
+			t.Errorf("swinging-door kept as many points as rolling-x, %d", len(dx))
+		}
+		if dx[0] != xs[0] || dx[len(dx)-1] != xs[len(xs)-1] {
+			t.Errorf("window %g: first and last points not kept", window)
+		}
+		k := 0
+		for i, x := range xs {
+			for x > dx[k+1] {
+				k++
+			}
+			y := dy[k] + (dy[k+1]-dy[k])*(x-dx[k])/(dx[k+1]-dx[k])
+			if d := math.Abs(y - ys[i]); d > tol*(1+1e-9) {
+				t.Fatalf("window %g: point %d (%g, %g) off by %g, more than twice the deviation", window, i, x, ys[i], d)
+			}
+		}
+		for i := 1; window > 0 && i < len(dx); i++ {
+			if dx[i]-dx[i-1] > window {
+				t.Errorf("points at x=%g and x=%g further apart than --window", dx[i-1], dx[i])
+			}
+		}
+	}
+	algorithm, deviation = "rolling-x", tol
+	if err := checkAlgorithm(); err == nil {
+		t.Error("expected error for --deviation with rolling-x")
+	}
+}
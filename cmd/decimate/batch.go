@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// concurrency is the number of inputs processed at once in batch mode.
+var concurrency int
+
+// inputs holds a task per input file given on the command line.
+var inputs []*task
+
+// task is the decimation of one input file. Output files are
+// named <dir>/<name>-<ycol>.<ext>[.<compression>].
+type task struct {
+	input                       string
+	dir, name, ext, compression string
+	// xname is the x column name, found once the input header is read.
+	xname string
+	// err is set when the task can't be run.
+	err error
+}
+
+// newTask names the output of input after the --output and --outdir flags
+// and checks the output format is supported for the input.
+func newTask(input string) (*task, error) {
+	if input != "-" {
+		if _, err := os.Stat(input); err != nil {
+			return nil, fmt.Errorf("opening %s. %s", input, err)
+		}
+	}
+	t := &task{input: input, dir: outputDir}
+	name := outputName
+	if strings.Contains(name, string(filepath.Separator)) || strings.Contains(name, "/") {
+		t.dir = filepath.Join(outputDir, filepath.Dir(name))
+		name = discardPath(name)
+	}
+	name, t.compression = splitCompression(name)
+	t.name, t.ext = splitFileExtension(name)
+	iname, icompression := splitCompression(discardPath(input))
+	iname, iext := splitFileExtension(iname)
+	if input == "-" {
+		iname, iext = "stdin", inputFormat
+	}
+	if t.name == "" || strings.HasPrefix(t.name, "<inputName>") {
+		t.name = iname
+	}
+	if t.ext == "" || t.ext == "<inputExtension>" {
+		// compressed input yields compressed output unless
+		// compression is given or output is meant for plotting tools.
+		t.ext = iext
+		if t.ext == "" {
+			t.ext = inputFormat
+		}
+		if t.ext == "" {
+			t.ext = "csv"
+		}
+		if t.compression == "" && !pgfplots && emitScript == "" {
+			t.compression = icompression
+		}
+		if f := formatFromExtension(t.ext); f == formatSpice || f == formatWAV || f == formatSVG {
+			t.ext = "csv"
+		}
+		if pgfplots {
+			t.ext = "dat"
+		}
+	} else if f := formatFromExtension(t.ext); f == formatSpice || f == formatWAV {
+		return nil, fmt.Errorf("writing .%s files is not supported", t.ext)
+	}
+	if f := formatFromExtension(t.ext); xFormat != "float" && f != formatCSV {
+		// Array formats hold float64 x values, which would be offsets from the lost epoch.
+		return nil, fmt.Errorf("--xformat %s needs delimited text output. .%s files hold float x values", xFormat, t.ext)
+	}
+	if pgfplots && t.compression != "" {
+		return nil, errors.New("--pgfplots needs uncompressed output")
+	}
+	if err := checkScriptParameters(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// expandInputs expands glob patterns in args to the files they match.
+// Files matched more than once are kept once.
+func expandInputs(args []string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		matches := []string{arg}
+		if arg != "-" && strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("bad pattern %q: %s", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", arg)
+			}
+		}
+		for _, path := range matches {
+			if !seen[filepath.Clean(path)] {
+				seen[filepath.Clean(path)] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// checkCollisions fails tasks whose output is named as that of an
+// earlier task, such as data.csv and data.npy written to .csv files.
+func checkCollisions(tasks []*task) {
+	seen := make(map[string]string)
+	for _, t := range tasks {
+		if t.err != nil {
+			continue
+		}
+		key := filepath.Join(t.dir, t.name) + "." + t.ext + "." + t.compression
+		if first, ok := seen[key]; ok {
+			t.err = fmt.Errorf("output named as that of %s", first)
+			continue
+		}
+		seen[key] = t.input
+	}
+}
+
+// runBatch runs tasks concurrently and prints a summary of their
+// results. An error is returned if any task failed.
+func runBatch(tasks []*task) error {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, t := range tasks {
+		if t.err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(t *task) {
+			defer wg.Done()
+			sem <- struct{}{}
+			t.err = run(t)
+			<-sem
+		}(t)
+	}
+	wg.Wait()
+	failed := 0
+	for _, t := range tasks {
+		if t.err != nil {
+			failed++
+		}
+	}
+	alert("processed %d files: %d succeeded, %d failed", len(tasks), len(tasks)-failed, failed)
+	for _, t := range tasks {
+		if t.err != nil {
+			fmt.Fprintf(logOut, "[ERR] %s: %s\n", t.input, t.err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(tasks))
+	}
+	return nil
+}
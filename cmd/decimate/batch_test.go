@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTaskXFormat(t *testing.T) {
+	defer func(out, xf string) { outputName, xFormat = out, xf }(outputName, xFormat)
+	input := filepath.Join(t.TempDir(), "log.csv")
+	if err := ioutil.WriteFile(input, []byte("t,v\n1600000000000000000,1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		xformat, output string
+		ok              bool
+	}{
+		{"float", "out.npy", true},
+		{"int", "out.csv", true},
+		{"int", "out.csv.gz", true},
+		{"time", "", true},
+		{"int", "out.npy", false},
+		{"int", "out.npz", false},
+		{"time", "out.mat", false},
+		{"int", "out.f64", false},
+		{"int", "out.svg", false},
+	} {
+		xFormat, outputName = test.xformat, test.output
+		_, err := newTask(input)
+		if test.ok && err != nil {
+			t.Errorf("--xformat %s -o %q: %s", test.xformat, test.output, err)
+		} else if !test.ok && err == nil {
+			t.Errorf("--xformat %s -o %q: expected error", test.xformat, test.output)
+		}
+	}
+}
+
+func TestCheckCollisions(t *testing.T) {
+	defer func(out, dir string) { outputName, outputDir = out, dir }(outputName, outputDir)
+	in := t.TempDir()
+	for _, name := range []string{"a/data.csv", "b/data.csv", "b/data.csv.gz", "b/data.npy", "b/other.csv"} {
+		path := filepath.Join(in, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("t,v\n1,1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputDir = filepath.Join(in, "out")
+	for _, test := range []struct {
+		output string
+		inputs []string
+		// collide holds whether each input collides with an earlier one.
+		collide []bool
+	}{
+		{"", []string{"a/data.csv", "b/data.csv"}, []bool{false, true}},
+		{"", []string{"a/data.csv", "b/other.csv", "b/data.csv"}, []bool{false, false, true}},
+		{"", []string{"a/data.csv", "b/data.csv.gz"}, []bool{false, false}},
+		{"", []string{"a/data.csv", "b/data.npy"}, []bool{false, false}},
+		{".csv", []string{"a/data.csv", "b/data.npy"}, []bool{false, true}},
+		{"<inputName>.csv", []string{"b/data.npy", "b/other.csv", "a/data.csv"}, []bool{false, false, true}},
+	} {
+		outputName = test.output
+		var tasks []*task
+		for _, input := range test.inputs {
+			tk, err := newTask(filepath.Join(in, input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks = append(tasks, tk)
+		}
+		checkCollisions(tasks)
+		for i, tk := range tasks {
+			if got := tk.err != nil; got != test.collide[i] {
+				t.Errorf("-o %q %v: input %s collides %v, want %v", test.output, test.inputs, test.inputs[i], got, test.collide[i])
+			}
+		}
+	}
+}
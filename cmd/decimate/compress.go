@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression formats, named after their file extension.
+const (
+	compressGzip = "gz"
+	compressZstd = "zst"
+	compressXz   = "xz"
+)
+
+// compressionMagic holds the first bytes of compressed files.
+var compressionMagic = []struct {
+	compression string
+	magic       []byte
+}{
+	{compressGzip, []byte{0x1f, 0x8b}},
+	{compressZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{compressXz, []byte{0xfd, '7', 'z', 'X', 'Z', 0}},
+}
+
+// splitCompression removes the compression extension of
+// filename, if any, and returns the compression format.
+func splitCompression(filename string) (name, compression string) {
+	name, ext := splitFileExtension(filename)
+	switch strings.ToLower(ext) {
+	case "gz", "gzip":
+		return name, compressGzip
+	case "zst", "zstd":
+		return name, compressZstd
+	case "xz":
+		return name, compressXz
+	}
+	return filename, ""
+}
+
+// dataExtension returns the extension of path which
+// sets its data format, ignoring any compression extension.
+func dataExtension(path string) string {
+	name, _ := splitCompression(discardPath(path))
+	_, ext := splitFileExtension(name)
+	return ext
+}
+
+// openDecompressed opens the file at path, or stdin if path is "-", decompressing
+// it if its first bytes are those of a supported compression format.
+func openDecompressed(path string) (io.ReadCloser, error) {
+	fi, br := os.Stdin, stdin
+	if path != "-" {
+		var err error
+		if fi, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		br = bufio.NewReaderSize(fi, 1<<16)
+	}
+	var err error
+	// Peek errors mean a short file, which is not compressed.
+	head, _ := br.Peek(6)
+	var r io.Reader
+	for _, c := range compressionMagic {
+		if !bytes.HasPrefix(head, c.magic) {
+			continue
+		}
+		switch c.compression {
+		case compressGzip:
+			r, err = gzip.NewReader(br)
+		case compressZstd:
+			var zr *zstd.Decoder
+			if zr, err = zstd.NewReader(br); err == nil {
+				r = zr.IOReadCloser()
+			}
+		case compressXz:
+			r, err = xz.NewReader(br)
+		}
+		if err != nil {
+			fi.Close()
+			return nil, err
+		}
+		rc := &readCloser{Reader: r, closers: []io.Closer{fi}}
+		if c, ok := r.(io.Closer); ok {
+			rc.closers = []io.Closer{c, fi}
+		}
+		return rc, nil
+	}
+	if path == "-" {
+		return &readCloser{Reader: br, closers: []io.Closer{fi}}, nil
+	}
+	// Uncompressed files are returned as is since some readers need an *os.File.
+	// Pipes can't be rewound and are read through br like stdin.
+	if _, err := fi.Seek(0, io.SeekStart); err != nil {
+		return &readCloser{Reader: br, closers: []io.Closer{fi}}, nil
+	}
+	return fi, nil
+}
+
+// createCompressed creates the file at path, compressing what is written
+// to it if path has the extension of a supported compression format.
+func createCompressed(path string) (io.WriteCloser, error) {
+	fo, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	var w io.WriteCloser
+	switch _, compression := splitCompression(path); compression {
+	case compressGzip:
+		w = gzip.NewWriter(fo)
+	case compressZstd:
+		w, err = zstd.NewWriter(fo)
+	case compressXz:
+		w, err = xz.NewWriter(fo)
+	default:
+		return fo, nil
+	}
+	if err != nil {
+		fo.Close()
+		return nil, err
+	}
+	return &writeCloser{Writer: w, closers: []io.Closer{w, fo}}, nil
+}
+
+// readCloser closes closers in order when closed.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error { return closeAll(r.closers) }
+
+// writeCloser closes closers in order when closed, which
+// flushes compressed data before closing the file.
+type writeCloser struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (w *writeCloser) Close() error { return closeAll(w.closers) }
+
+func closeAll(closers []io.Closer) (err error) {
+	for _, c := range closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestOpenDecompressedFIFO(t *testing.T) {
+	const data = "t,v\n0,1\n1,2\n2,3\n"
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skip("can't create named pipe:", err)
+	}
+	go func() {
+		// Opening a named pipe to write blocks until it is opened to read.
+		fp, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		fp.WriteString(data)
+		fp.Close()
+	}()
+	if got := readDecompressed(t, path); got != data {
+		t.Errorf("got %q, want %q", got, data)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	const data = "t,v\n0,1\n1,2\n2,3\n"
+	dir := t.TempDir()
+	for _, name := range []string{"data.csv", "data.csv.gz", "data.csv.zst", "data.csv.xz"} {
+		path := filepath.Join(dir, name)
+		w, err := createCompressed(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, data); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := readDecompressed(t, path); got != data {
+			t.Errorf("%s: got %q, want %q", name, got, data)
+		}
+	}
+}
+
+// readDecompressed returns the decompressed contents of the file at path.
+func readDecompressed(t *testing.T, path string) string {
+	t.Helper()
+	rc, err := openDecompressed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// jobFile is the path of a YAML, TOML or JSON file declaring decimation jobs.
+var jobFile string
+
+// jobSpec is a job declared in a job file. Its values
+// are those of the root command's flags, by flag name.
+type jobSpec struct {
+	name   string
+	inputs []string
+	values map[string]interface{}
+}
+
+// readJobFile reads the jobs declared in the file at path. Jobs are listed
+// under "jobs" and other top level keys hold values shared by all jobs.
+// A file without "jobs" declares a single job. Paths are relative to the file.
+// Keys are checked to be the names of flags.
+func readJobFile(path string, flags *pflag.FlagSet) ([]jobSpec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(b, &doc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &doc)
+	case ".toml":
+		_, err = toml.Decode(string(b), &doc)
+	default:
+		return nil, fmt.Errorf("unknown job file extension %q. want .yaml, .toml or .json", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	shared := make(map[string]interface{})
+	for k, v := range doc {
+		if k != "jobs" {
+			shared[k] = v
+		}
+	}
+	list := []interface{}{map[string]interface{}{}}
+	switch v := doc["jobs"].(type) {
+	case nil:
+	case []interface{}:
+		list = v
+	case []map[string]interface{}:
+		// TOML arrays of tables.
+		list = list[:0]
+		for _, m := range v {
+			list = append(list, m)
+		}
+	default:
+		return nil, fmt.Errorf("%s: jobs must be a list", path)
+	}
+	dir := filepath.Dir(path)
+	var jobs []jobSpec
+	for i, v := range list {
+		m, ok := stringMap(v)
+		if !ok {
+			return nil, fmt.Errorf("%s: job %d is not a table of values", path, i+1)
+		}
+		spec := jobSpec{name: fmt.Sprintf("job %d", i+1), values: make(map[string]interface{})}
+		for _, src := range []map[string]interface{}{shared, m} {
+			for k, v := range src {
+				spec.values[k] = v
+			}
+		}
+		if err := spec.resolve(dir, flags); err != nil {
+			return nil, fmt.Errorf("%s: %s: %s", path, spec.name, err)
+		}
+		jobs = append(jobs, spec)
+	}
+	return jobs, nil
+}
+
+// resolve takes the name and inputs out of the job's values, checks the
+// rest name flags and makes paths relative to the job file directory dir.
+func (spec *jobSpec) resolve(dir string, flags *pflag.FlagSet) error {
+	if v, ok := spec.values["name"]; ok {
+		spec.name = fmt.Sprint(v)
+		delete(spec.values, "name")
+	}
+	switch v := spec.values["inputs"].(type) {
+	case string:
+		spec.inputs = []string{v}
+	case []interface{}:
+		for _, s := range v {
+			spec.inputs = append(spec.inputs, fmt.Sprint(s))
+		}
+	}
+	delete(spec.values, "inputs")
+	if len(spec.inputs) == 0 {
+		return errors.New("no inputs")
+	}
+	for i, in := range spec.inputs {
+		spec.inputs[i] = jobPath(dir, in)
+	}
+	for k, v := range spec.values {
+		if k == "job" || flags.Lookup(k) == nil {
+			return fmt.Errorf("unknown key %q. keys are inputs, name and the long names of flags", k)
+		}
+		if k == "output" || k == "outdir" {
+			spec.values[k] = jobPath(dir, fmt.Sprint(v))
+		}
+	}
+	return nil
+}
+
+// jobPath returns path relative to the directory dir of a job file.
+func jobPath(dir, path string) string {
+	if path == "-" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	joined := filepath.Join(dir, path)
+	if strings.HasSuffix(path, "/") {
+		// Join drops the trailing separator naming a directory.
+		joined += "/"
+	}
+	return joined
+}
+
+// applyJob sets the flags of the root command to the values of spec.
+// Flags given on the command line are kept and others are reset to
+// their default value first so jobs do not inherit each other's values.
+func applyJob(flags *pflag.FlagSet, spec jobSpec) error {
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if !f.Changed && f.Name != "job" && err == nil {
+			err = f.Value.Set(f.DefValue)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	// The delimiter is set first since it joins list values.
+	keys := make([]string, 0, len(spec.values))
+	for k := range spec.values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] == "delimiter" || keys[j] != "delimiter" && keys[i] < keys[j] })
+	for _, k := range keys {
+		f := flags.Lookup(k)
+		if f.Changed {
+			continue
+		}
+		sep := inputSeparator
+		if strings.TrimSuffix(sep, "s") == "tab" || sep == "\\t" {
+			sep = "\t"
+		}
+		s, err := flagText(spec.values[k], sep)
+		if err != nil {
+			return fmt.Errorf("%s: %s", k, err)
+		}
+		if err := f.Value.Set(s); err != nil {
+			return fmt.Errorf("%s: %s", k, err)
+		}
+	}
+	return nil
+}
+
+// flagText returns the command line text of a job file value.
+// Lists and tables are joined by sep, tables as key=value pairs.
+func flagText(v interface{}, sep string) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			s, err := flagText(e, sep)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, sep), nil
+	}
+	m, ok := stringMap(v)
+	if !ok {
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+	var parts []string
+	for k, e := range m {
+		s, err := flagText(e, sep)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, k+"="+s)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, sep), nil
+}
+
+// stringMap returns v as a map with string keys. YAML tables
+// are decoded with keys of any type.
+func stringMap(v interface{}) (map[string]interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = e
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// runJobs runs the jobs of a job file one after the other
+// and reports those which fail without stopping.
+func runJobs(flags *pflag.FlagSet, jobs []jobSpec) error {
+	failed := 0
+	for _, spec := range jobs {
+		err := applyJob(flags, spec)
+		if err == nil {
+			err = checkParameters(spec.inputs)
+		}
+		if err == nil {
+			alert("running %s", spec.name)
+			err = runInputs()
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(logOut, "[ERR] %s: %s\n", spec.name, err)
+		}
+	}
+	if len(jobs) > 1 {
+		alert("ran %d jobs: %d succeeded, %d failed", len(jobs), len(jobs)-failed, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs failed", failed, len(jobs))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestApplyJob(t *testing.T) {
+	defer func(sep string) { inputSeparator = sep }(inputSeparator)
+	var tol float64
+	var ycols string
+	var points int
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Float64Var(&tol, "tolerance", 0.1, "")
+	flags.StringVar(&ycols, "ycols", "", "")
+	flags.IntVar(&points, "points", 0, "")
+	flags.StringVar(&inputSeparator, "delimiter", ",", "")
+	if err := flags.Parse([]string{"--tolerance", "0.5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := applyJob(flags, jobSpec{values: map[string]interface{}{
+		"tolerance": 0.01,
+		"ycols":     []interface{}{"a", "b"},
+		"points":    10,
+		"delimiter": ";",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tol != 0.5 {
+		t.Errorf("tolerance %g given on the command line was overridden", tol)
+	}
+	if ycols != "a;b" || points != 10 || inputSeparator != ";" {
+		t.Errorf("got ycols %q, points %d and delimiter %q from first job", ycols, points, inputSeparator)
+	}
+
+	err = applyJob(flags, jobSpec{values: map[string]interface{}{"ycols": "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tol != 0.5 {
+		t.Errorf("tolerance %g given on the command line was reset", tol)
+	}
+	if ycols != "c" || points != 0 || inputSeparator != "," {
+		t.Errorf("got ycols %q, points %d and delimiter %q, want values of first job reset", ycols, points, inputSeparator)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// muxMode selects writing all columns to a single stream on stdout.
+// It is empty when writing a file per column.
+var muxMode string
+
+// Multiplexed stream layouts.
+const (
+	// muxTagged writes rows of column name, x and y.
+	muxTagged = "tagged"
+	// muxWide writes rows of x and a cell per column. Points of
+	// several columns at the same x share a row, other cells are empty.
+	muxWide = "wide"
+)
+
+// logOut is where alerts and errors are printed. It is
+// stderr when data is written to stdout.
+var logOut io.Writer = os.Stdout
+
+// muxWriter writes the points of all jobs to a single delimited text stream.
+type muxWriter struct {
+	*csv.Writer
+	bw *bufio.Writer
+	xc xCodec
+	// yname holds the column names of tagged streams.
+	yname  []string
+	record []string
+	// pending is set when record holds a wide row at x which is not written yet.
+	pending bool
+	x       float64
+}
+
+func newMuxWriter(w io.Writer, xname string, ynames []string, xc xCodec) (*muxWriter, error) {
+	bw := bufio.NewWriterSize(w, 1<<16)
+	m := &muxWriter{Writer: csv.NewWriter(bw), bw: bw, xc: xc, yname: ynames}
+	m.Comma = outputSeparator()
+	header := []string{"column", xname, "y"}
+	if muxMode == muxWide {
+		header = append([]string{xname}, ynames...)
+	}
+	m.record = make([]string, len(header))
+	if !noHeader {
+		if err := m.Write(header); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *muxWriter) write(col int, x, y float64) error {
+	if muxMode == muxTagged {
+		m.record[0], m.record[1], m.record[2] = m.yname[col], m.xc.format(x), fmt.Sprintf(floatFormat, y)
+		return m.Write(m.record)
+	}
+	if m.pending && (x != m.x || m.record[col+1] != "") {
+		if err := m.Write(m.record); err != nil {
+			return err
+		}
+		m.pending = false
+	}
+	if !m.pending {
+		for i := range m.record {
+			m.record[i] = ""
+		}
+		m.record[0], m.x, m.pending = m.xc.format(x), x, true
+	}
+	m.record[col+1] = fmt.Sprintf(floatFormat, y)
+	return nil
+}
+
+func (m *muxWriter) flush() error {
+	if m.pending {
+		if err := m.Write(m.record); err != nil {
+			return err
+		}
+		m.pending = false
+	}
+	m.Flush()
+	if err := m.Error(); err != nil {
+		return err
+	}
+	return m.bw.Flush()
+}
+
+// muxSink is the sink of a job writing to a muxWriter.
+type muxSink struct {
+	m   *muxWriter
+	col int
+}
+
+func (s muxSink) write(x, y float64) error { return s.m.write(s.col, x, y) }
+
+// Close flushes the shared stream, which stays open.
+func (s muxSink) Close() error { return s.m.flush() }
+
+// stdin is buffered so its format can be told by its first bytes.
+var stdin = bufio.NewReaderSize(os.Stdin, 1<<16)
+
+// spoolStdin copies stdin to a temporary file for inputs which are read
+// more than once. The caller removes the file.
+func spoolStdin() (string, error) {
+	f, err := ioutil.TempFile("", "decimate-stdin-")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, stdin)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMuxWideRows(t *testing.T) {
+	defer func(mode, ff, sep string, nh, comma bool) {
+		muxMode, floatFormat, inputSeparator, noHeader, enforceComma = mode, ff, sep, nh, comma
+	}(muxMode, floatFormat, inputSeparator, noHeader, enforceComma)
+	muxMode, floatFormat, inputSeparator, noHeader, enforceComma = muxWide, "%g", ",", false, false
+	type point struct {
+		col  int
+		x, y float64
+	}
+	for _, test := range []struct {
+		name   string
+		points []point
+		want   string
+	}{
+		{
+			name:   "columns at the same x share a row",
+			points: []point{{0, 1, 10}, {1, 1, 20}, {2, 1, 30}, {0, 2, 11}, {2, 2, 31}},
+			want:   "t,a,b,c\n1,10,20,30\n2,11,,31\n",
+		},
+		{
+			name:   "columns in any order",
+			points: []point{{2, 1, 30}, {0, 1, 10}, {1, 2, 21}},
+			want:   "t,a,b,c\n1,10,,30\n2,,21,\n",
+		},
+		{
+			name:   "a new x starts a row",
+			points: []point{{0, 1, 10}, {1, 2, 21}, {0, 3, 12}},
+			want:   "t,a,b,c\n1,10,,\n2,,21,\n3,12,,\n",
+		},
+		{
+			name:   "repeated x of a column starts a row",
+			points: []point{{0, 1, 10}, {0, 1, 11}, {1, 1, 20}},
+			want:   "t,a,b,c\n1,10,,\n1,11,20,\n",
+		},
+		{
+			name: "no points",
+			want: "t,a,b,c\n",
+		},
+	} {
+		var buf bytes.Buffer
+		m, err := newMuxWriter(&buf, "t", []string{"a", "b", "c"}, newXCodec("float"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range test.points {
+			if err := m.write(p.col, p.x, p.y); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := m.flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, strings.TrimSpace(got), strings.TrimSpace(test.want))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+const (
+	// rowBatch is the number of rows parsed before handing them to workers.
+	rowBatch = 1024
+	// batchBuffer is the number of batches a worker may have pending
+	// before the parser waits for it.
+	batchBuffer = 8
+)
+
+// runParallel reads all rows of src and processes each job in its own
+// goroutine. Rows are parsed once and shared among workers in read-only
+// batches of rows of len(yxIdx) values. Each job sees the same points in
+// the same order as when processed sequentially so output is identical.
+// Reading stops at the first error of a job.
+func runParallel(src source, yxIdx []int, jobs []*job) error {
+	width := len(yxIdx)
+	batches := make([]chan []float64, len(jobs))
+	errs := make([]error, len(jobs))
+	failed := make(chan struct{})
+	var failOnce sync.Once
+	var wg sync.WaitGroup
+	for i := range jobs {
+		batches[i] = make(chan []float64, batchBuffer)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// After an error batches are drained so the parser does not block.
+			for batch := range batches[i] {
+				for r := 0; r < len(batch) && errs[i] == nil; r += width {
+					errs[i] = jobs[i].process(batch[r+width-1], batch[r+i])
+				}
+				if errs[i] != nil {
+					failOnce.Do(func() { close(failed) })
+				}
+			}
+		}(i)
+	}
+	err := parseBatches(src, yxIdx, func(batch []float64) bool {
+		select {
+		case <-failed:
+			return false
+		default:
+		}
+		for _, c := range batches {
+			select {
+			case c <- batch:
+			case <-failed:
+				return false
+			}
+		}
+		return true
+	})
+	for _, c := range batches {
+		close(c)
+	}
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseBatches reads rows of src into batches which are handed to send.
+// The last batch ends with a row of NaN which flushes the steppers.
+// Reading stops early if send returns false.
+func parseBatches(src source, yxIdx []int, send func([]float64) bool) error {
+	width := len(yxIdx)
+	for {
+		batch := make([]float64, 0, rowBatch*width)
+		for len(batch) < cap(batch) {
+			row := batch[len(batch) : len(batch)+width]
+			err := src.read(yxIdx, row)
+			if errors.Is(err, io.EOF) {
+				alert("finished writing files")
+				for i := range row {
+					row[i] = math.NaN()
+				}
+				send(batch[:len(batch)+width])
+				return nil
+			} else if err != nil {
+				return err
+			}
+			batch = batch[:len(batch)+width]
+		}
+		if !send(batch) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParallelMatchesSequential(t *testing.T) {
+	silent = true
+	defer func() { silent = false }()
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	var b strings.Builder
+	b.WriteString("t,a,b,c\n")
+	for i := 0; i < 5*rowBatch+17; i++ {
+		x := float64(i) / 100
+		bv := fmt.Sprint(math.Cos(3 * x))
+		if i%100 == 50 {
+			bv = "NaN"
+		}
+		fmt.Fprintf(&b, "%g,%g,%s,%g\n", x, math.Sin(x)+0.01*math.Sin(37*x), bv, float64(i%7))
+	}
+	if err := ioutil.WriteFile(input, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, alg := range []string{"rolling-x", "rdp"} {
+		algorithm = alg
+		seq := decimateWith(t, input, filepath.Join(dir, alg, "seq"), runSequential)
+		par := decimateWith(t, input, filepath.Join(dir, alg, "par"), runParallel)
+		for i := range seq {
+			if !bytes.Equal(seq[i], par[i]) {
+				t.Errorf("%s: column %d differs between sequential and parallel runs", alg, i)
+			}
+		}
+	}
+	algorithm = "rolling-x"
+}
+
+// decimateWith decimates columns a, b and c of input into outdir
+// with runner and returns the contents of the files written.
+func decimateWith(t *testing.T, input, outdir string, runner func(source, []int, []*job) error) [][]byte {
+	t.Helper()
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	xc := newXCodec("float")
+	src, err := openSource(input, xc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	tk := &task{input: input, dir: outdir, name: "out", ext: "csv", xname: "t"}
+	var jobs []*job
+	for _, y := range []string{"a", "b", "c"} {
+		j := &job{task: tk, xname: "t", yname: y, tolerance: 0.01, stepper: newStepper(0.01)}
+		if j.sink, err = createSink(getJobName(*j), "t", y, 0.01, xc); err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, j)
+	}
+	err = runner(src, []int{1, 2, 3, 0}, jobs)
+	for _, j := range jobs {
+		if cerr := j.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out [][]byte
+	for _, j := range jobs {
+		b, err := ioutil.ReadFile(getJobName(*j))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func TestParallelStopsOnError(t *testing.T) {
+	silent = true
+	defer func() { silent = false }()
+	src := &countingSource{rows: 1000 * rowBatch}
+	errSink := errors.New("sink failed")
+	var jobs []*job
+	for i := 0; i < 3; i++ {
+		j := &job{task: &task{}, tolerance: 0, stepper: newStepper(0)}
+		j.sink = &failingSink{after: 10, err: errSink}
+		jobs = append(jobs, j)
+	}
+	if err := runParallel(src, []int{1, 2, 3, 0}, jobs); !errors.Is(err, errSink) {
+		t.Fatalf("got error %v, want %v", err, errSink)
+	}
+	// Workers may hold a few pending batches when the first one fails.
+	if max := (len(jobs)*batchBuffer + 4) * rowBatch; src.n > max {
+		t.Errorf("read %d rows, want at most %d when a job fails early", src.n, max)
+	}
+}
+
+// countingSource yields rows of alternating values and counts the rows read.
+type countingSource struct {
+	rows, n int
+}
+
+func (s *countingSource) header() []string { return []string{"t", "a", "b", "c"} }
+
+func (s *countingSource) read(cols []int, dst []float64) error {
+	if s.n == s.rows {
+		return io.EOF
+	}
+	for i := range dst {
+		dst[i] = float64(s.n % 2)
+	}
+	dst[len(dst)-1] = float64(s.n)
+	s.n++
+	return nil
+}
+
+func (s *countingSource) Close() error { return nil }
+
+// failingSink returns err once after points have been written.
+type failingSink struct {
+	after int
+	err   error
+}
+
+func (s *failingSink) write(x, y float64) error {
+	if s.after == 0 {
+		return s.err
+	}
+	s.after--
+	return nil
+}
+
+func (s *failingSink) Close() error { return nil }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// pgfplots writes whitespace separated .dat tables and a .tex snippet plotting them.
+var pgfplots bool
+
+// datSink writes a whitespace separated table pgfplots reads
+// and keeps track of the range of the values written, which for
+// integer x are not relative to the x codec's epoch.
+type datSink struct {
+	io.Closer
+	w                      *bufio.Writer
+	xc                     xCodec
+	xmin, xmax, ymin, ymax float64
+}
+
+func newDatSink(wc io.WriteCloser, xname, yname string, xc xCodec) *datSink {
+	s := &datSink{
+		Closer: wc, w: bufio.NewWriter(wc), xc: xc,
+		xmin: math.Inf(1), xmax: math.Inf(-1),
+		ymin: math.Inf(1), ymax: math.Inf(-1),
+	}
+	if !noHeader {
+		s.w.WriteString(datColumnName(xname) + " " + datColumnName(yname) + "\n")
+	}
+	return s
+}
+
+// datColumnName replaces characters pgfplots does not accept in column
+// names, such as whitespace and the '#' and '%' comment characters.
+func datColumnName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-.()", r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func (s *datSink) write(x, y float64) error {
+	xs, ys := s.xc.format(x), fmt.Sprintf(floatFormat, y)
+	if xw, err := strconv.ParseFloat(xs, 64); err == nil && !math.IsNaN(xw) {
+		s.xmin, s.xmax = math.Min(s.xmin, xw), math.Max(s.xmax, xw)
+	}
+	if yw, err := strconv.ParseFloat(ys, 64); err == nil && !math.IsNaN(yw) {
+		s.ymin, s.ymax = math.Min(s.ymin, yw), math.Max(s.ymax, yw)
+	}
+	_, err := s.w.WriteString(xs + " " + ys + "\n")
+	return err
+}
+
+func (s *datSink) Close() error {
+	if err := s.w.Flush(); err != nil {
+		s.Closer.Close()
+		return err
+	}
+	return s.Closer.Close()
+}
+
+// writePgfplotsTeX writes a .tex snippet named after the output with an
+// axis holding one \addplot per job, limited to the range of all data.
+func writePgfplotsTeX(t *task, jobs []*job) error {
+	path := filepath.Join(t.dir, t.name+".tex")
+	xmin, xmax, ymin, ymax := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for _, j := range jobs {
+		s := j.sink.(*datSink)
+		xmin, xmax = math.Min(xmin, s.xmin), math.Max(xmax, s.xmax)
+		ymin, ymax = math.Min(ymin, s.ymin), math.Max(ymax, s.ymax)
+	}
+	var b strings.Builder
+	b.WriteString("% Generated by decimate. Requires \\usepackage{pgfplots} in the preamble.\n")
+	b.WriteString("\\begin{tikzpicture}\n\\begin{axis}[\n")
+	// Empty ranges are left for pgfplots to choose.
+	if xmax > xmin {
+		fmt.Fprintf(&b, "\txmin=%s, xmax=%s,\n", texNumber(xmin), texNumber(xmax))
+	}
+	if ymax > ymin {
+		fmt.Fprintf(&b, "\tymin=%s, ymax=%s,\n", texNumber(ymin), texNumber(ymax))
+	}
+	fmt.Fprintf(&b, "\txlabel={%s},\n", texEscape(t.xname))
+	b.WriteString("\tlegend pos=outer north east,\n]\n")
+	for _, j := range jobs {
+		fmt.Fprintf(&b, "\\addplot+[no marks] table[x index=0, y index=1] {%s};\n", filepath.ToSlash(getJobName(*j)))
+		fmt.Fprintf(&b, "\\addlegendentry{%s}\n", texEscape(j.yname))
+	}
+	b.WriteString("\\end{axis}\n\\end{tikzpicture}\n")
+	alert("creating file %s", path)
+	return ioutil.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func texNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// texEscape escapes characters with special meaning in LaTeX text.
+func texEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "$", `\$`, "&", `\&`,
+		"#", `\#`, "%", `\%`, "_", `\_`, "^", `\^{}`, "~", `\~{}`,
+	).Replace(s)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPgfplotsIntLimits(t *testing.T) {
+	silent = true
+	defer func() { silent = false }()
+	dir := t.TempDir()
+	xc := newXCodec("int")
+	if _, err := xc.parse("1600000000000000000"); err != nil {
+		t.Fatal(err)
+	}
+	tk := &task{dir: dir, name: "data", ext: "dat", xname: "t"}
+	j := &job{task: tk, xname: "t", yname: "v"}
+	fp, err := os.Create(getJobName(*j))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newDatSink(fp, "t", "v", xc)
+	j.sink = s
+	for _, x := range []float64{0, 50000, 99000} {
+		if err := s.write(x, x/1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s.xmin != 1.6e18 || s.xmax != 1.6e18+99000 {
+		t.Errorf("x range [%g, %g] is not that of the values written", s.xmin, s.xmax)
+	}
+	if err := writePgfplotsTeX(tk, []*job{j}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "data.tex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "xmin=1.6e+18, xmax=1.600000000000099e+18,") || !strings.Contains(string(b), "ymin=0, ymax=99,") {
+		t.Errorf("unexpected axis limits:\n%s", b)
+	}
+}
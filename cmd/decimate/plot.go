@@ -0,0 +1,354 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/soypat/go-decim/decimplot"
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+	_ "gonum.org/v1/plot/vg/vgpdf"
+	_ "gonum.org/v1/plot/vg/vgsvg"
+)
+
+// plot flags
+var plotOutput, plotWidth, plotHeight string
+var plotOverlay, plotResidual bool
+
+// plotBand is set when the tolerance of the decimated data is known
+// so that it is drawn in the residual panel.
+var plotBand bool
+
+// plot image size in pixels and resolution obtained from flags.
+var plotWidthPx, plotHeightPx, plotDPI float64
+
+func init() {
+	rootCmd.AddCommand(plotCmd)
+	plotCmd.Flags().StringVarP(&xFlag, "xcol", "x", "", "X column name of the original file. May be column number starting at 1. (required)")
+	_ = plotCmd.MarkFlagRequired("xcol")
+	plotCmd.Flags().StringVarP(&yFlag, "ycols", "y", "", "Y column names/numbers separated by delimiter. Numbering starts at 1. All columns if not set")
+	plotCmd.Flags().StringVarP(&inputSeparator, "delimiter", "d", ",", "Delimiter token of original and decimated files")
+	plotCmd.Flags().StringVar(&xFormat, "xformat", "float", "X column format: 'float', 'int', 'time' or a Go time layout")
+	plotCmd.Flags().Float64VarP(&tolerance, "tolerance", "t", 0.1, "Downsampling y-value tolerance when no decimated files are given")
+	plotCmd.Flags().BoolVarP(&interp, "interp", "i", false, "Use more aggressive interpolating algorithm when no decimated files are given")
+	plotCmd.Flags().StringVarP(&plotOutput, "output", "o", "", "Output image filename, named after ycolumn. Extension sets the format: png, jpg, tif, svg or pdf. Defaults to the input name and png")
+	plotCmd.Flags().StringVar(&plotWidth, "width", "1024", "Image width in pixels or physical units (cm, mm, in)")
+	plotCmd.Flags().StringVar(&plotHeight, "height", "640", "Image height in pixels or physical units (cm, mm, in)")
+	plotCmd.Flags().Float64Var(&dpi, "dpi", 0, "Dots per inch of the image. 96 if not set")
+	plotCmd.Flags().BoolVar(&plotOverlay, "overlay", true, "Draw original and decimated data on the same axes. If false they are drawn one above the other")
+	plotCmd.Flags().BoolVar(&plotResidual, "residual", false, "Add a panel with the difference between original and decimated data")
+	plotCmd.Flags().StringVar(&inputFormat, "input-format", "", "Input data format, overriding the file extension: "+strings.Join(inputFormats, ", "))
+	plotCmd.Flags().StringVar(&binDtype, "dtype", "float64", "Raw binary (.bin) value type: float32 or float64")
+	plotCmd.Flags().StringVar(&binEndian, "endian", "little", "Raw binary byte order: little or big")
+	plotCmd.Flags().IntVar(&binColumns, "ncols", 0, "Number of columns of raw binary input")
+	plotCmd.Flags().StringVar(&binLayout, "layout", "interleaved", "Raw binary layout: interleaved rows or columns stored one after the other")
+	plotCmd.Flags().IntVar(&spiceStep, "step", 1, "Stepped run of SPICE .raw input to read, starting at 1")
+}
+
+// plotCmd draws original and decimated data for comparison.
+var plotCmd = &cobra.Command{
+	Use:   "plot original [decimated...]",
+	Short: "Draw original and decimated data for comparison",
+	Long: `plot draws each y column of the original file along with its
+decimated version as a PNG, JPEG, TIFF, SVG or PDF image. The legend
+holds the number of points of each series.
+
+Decimated data is read from the files given after the original, as
+written by decimate, or decimated with --tolerance if none are given.
+With --residual a panel below shows the original minus the linearly
+interpolated decimated data, and the tolerance when it is known.
+
+	Example:
+
+decimate plot -x time -y "a,b" -t 0.01 --residual -o cmp.svg data.csv
+
+Writes cmp-a.svg and cmp-b.svg.
+
+decimate plot -x time --overlay=false data.csv data-*.csv
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("requires at least one argument as input filename")
+		}
+		if strings.TrimSuffix(inputSeparator, "s") == "tab" || inputSeparator == "\\t" {
+			yFlag = strings.ReplaceAll(yFlag, "\\t", "\t")
+			inputSeparator = "\t"
+		}
+		if len(inputSeparator) != 1 {
+			return errors.New("delimiter should be one character. '\\t' and 'tab' work as an option")
+		}
+		if yFlag == "" {
+			yFlag = "*"
+		}
+		plotDPI = dpi
+		if plotDPI == 0 {
+			plotDPI = 96
+		}
+		var err error
+		if plotWidthPx, err = parseLength(plotWidth, plotDPI); err != nil {
+			return err
+		}
+		if plotHeightPx, err = parseLength(plotHeight, plotDPI); err != nil {
+			return err
+		}
+		if _, ext := splitFileExtension(discardPath(plotOutput)); ext != "" && imageFormat(ext) == "" {
+			return fmt.Errorf("unknown image format %q. want png, jpg, tif, svg or pdf", ext)
+		}
+		plotBand = len(args) == 1 || cmd.Flags().Changed("tolerance")
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := plotColumns(args[0], args[1:]); err != nil {
+			fmt.Fprintf(logOut, "[ERR] %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// imageFormat returns the format of image files with extension
+// ext or an empty string if the format is not supported.
+func imageFormat(ext string) string {
+	switch ext = strings.ToLower(ext); ext {
+	case "jpeg":
+		return "jpg"
+	case "tiff":
+		return "tif"
+	case "png", "jpg", "tif", "svg", "pdf":
+		return ext
+	}
+	return ""
+}
+
+// plotColumns draws the y columns of original given by flags, or those
+// the decimated files hold if any, to an image per column.
+func plotColumns(original string, decimated []string) error {
+	// Sharing the x codec keeps integer and timestamp epochs consistent across files.
+	xc := newXCodec(xFormat)
+	xname, ynames, cols, err := readColumns(original, xc)
+	if err != nil {
+		return fmt.Errorf("%s: %s", original, err)
+	}
+	xs := cols[len(cols)-1]
+	var decs []*xySlices
+	var decNames []string
+	if len(decimated) == 0 {
+		for i, yname := range ynames {
+			ps := &pointSink{}
+			if err := decimateColumn(xs, cols[i], tolerance, ps); err != nil {
+				return err
+			}
+			decs, decNames = append(decs, &ps.xySlices), append(decNames, yname)
+		}
+	} else {
+		paths, err := expandInputs(decimated)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			dx, dy, yname, err := readDecimated(path, ynames, xc)
+			if err != nil {
+				return fmt.Errorf("%s: %s", path, err)
+			}
+			decs, decNames = append(decs, &xySlices{x: dx, y: dy}), append(decNames, yname)
+		}
+	}
+
+	dir, name := filepath.Split(plotOutput)
+	name, ext := splitFileExtension(name)
+	if name == "" {
+		name, _ = splitCompression(discardPath(original))
+		name, _ = splitFileExtension(name)
+		if original == "-" {
+			name = "stdin"
+		}
+	}
+	if ext == "" {
+		ext = "png"
+	}
+	xlabel := xname
+	switch xFormat {
+	case "float":
+	case "int":
+		xlabel = fmt.Sprintf("%s from %s", xname, xc.format(0))
+	default:
+		xlabel = fmt.Sprintf("%s (ns from %s)", xname, xc.format(0))
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	for i, dec := range decs {
+		yname := decNames[i]
+		orig := &xySlices{x: xs, y: cols[findStringInSlice(yname, ynames)]}
+		panels, err := comparisonPlots(orig, dec, xlabel, yname)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, name+"-"+replaceCutset(yname, badFilenameChar, "-")+"."+ext)
+		alert("creating file %s", path)
+		if err := saveImage(path, imageFormat(ext), panels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// comparisonPlots returns the panels comparing orig with its decimated version dec.
+func comparisonPlots(orig, dec *xySlices, xlabel, yname string) ([]*plot.Plot, error) {
+	newPanel := func() *plot.Plot {
+		p := plot.New()
+		p.X.Label.Text, p.Y.Label.Text = xlabel, yname
+		p.Legend.Top = true
+		p.Add(plotter.NewGrid())
+		return p
+	}
+	top := newPanel()
+	top.Title.Text = yname
+	if err := addSeries(top, orig, fmt.Sprintf("original (%d points)", orig.Len()), plotutil.Color(0), true); err != nil {
+		return nil, err
+	}
+	panels := []*plot.Plot{top}
+	decPanel := top
+	if !plotOverlay {
+		decPanel = newPanel()
+		panels = append(panels, decPanel)
+	}
+	if err := addSeries(decPanel, dec, fmt.Sprintf("decimated (%d points)", dec.Len()), plotutil.Color(1), false); err != nil {
+		return nil, err
+	}
+	if plotResidual {
+		res := &xySlices{x: orig.x, y: make([]float64, orig.Len())}
+		for i, x := range orig.x {
+			yi, _ := interpolate(dec.x, dec.y, x)
+			res.y[i] = orig.y[i] - yi
+		}
+		p := newPanel()
+		p.Y.Label.Text = "residual"
+		if err := addSeries(p, res, "original - decimated", plotutil.Color(2), true); err != nil {
+			return nil, err
+		}
+		if plotBand && orig.Len() > 0 {
+			for _, sign := range []float64{1, -1} {
+				l, err := plotter.NewLine(plotter.XYs{{X: top.X.Min, Y: sign * tolerance}, {X: top.X.Max, Y: sign * tolerance}})
+				if err != nil {
+					return nil, err
+				}
+				l.Color, l.Dashes = color.Gray{Y: 96}, []vg.Length{vg.Points(4), vg.Points(2)}
+				p.Add(l)
+				if sign > 0 {
+					p.Legend.Add(fmt.Sprintf("tolerance ±%g", tolerance), l)
+				}
+			}
+		}
+		panels = append(panels, p)
+	}
+	// Panels share the x range of the original data.
+	for _, p := range panels[1:] {
+		p.X.Min, p.X.Max = top.X.Min, top.X.Max
+	}
+	return panels, nil
+}
+
+// addSeries adds xy to p as lines broken at NaN values with a single legend entry.
+// Decimating lines are drawn no more than half a pixel away from the data.
+func addSeries(p *plot.Plot, xy *xySlices, label string, c color.Color, decimating bool) error {
+	labeled := false
+	start := 0
+	for i := 0; i <= xy.Len(); i++ {
+		if i < xy.Len() && !math.IsNaN(xy.x[i]) && !math.IsNaN(xy.y[i]) {
+			continue
+		}
+		if i > start {
+			seg := &xySlices{x: xy.x[start:i], y: xy.y[start:i]}
+			var thumb plot.Thumbnailer
+			if decimating {
+				l, err := decimplot.NewLine(seg)
+				if err != nil {
+					return err
+				}
+				l.Color, l.DPI = c, plotDPI
+				p.Add(l)
+				thumb = l
+			} else {
+				l, err := plotter.NewLine(seg)
+				if err != nil {
+					return err
+				}
+				l.Color = c
+				p.Add(l)
+				thumb = l
+			}
+			if !labeled {
+				p.Legend.Add(label, thumb)
+				labeled = true
+			}
+		}
+		start = i + 1
+	}
+	return nil
+}
+
+// saveImage draws panels one above the other to an image file at path.
+func saveImage(path, format string, panels []*plot.Plot) error {
+	w := vg.Length(plotWidthPx/plotDPI) * vg.Inch
+	h := vg.Length(plotHeightPx/plotDPI) * vg.Inch
+	var c vg.CanvasWriterTo
+	switch format {
+	case "png", "jpg", "tif":
+		img := vgimg.NewWith(vgimg.UseWH(w, h), vgimg.UseDPI(int(plotDPI)))
+		switch format {
+		case "png":
+			c = vgimg.PngCanvas{Canvas: img}
+		case "jpg":
+			c = vgimg.JpegCanvas{Canvas: img}
+		default:
+			c = vgimg.TiffCanvas{Canvas: img}
+		}
+	default:
+		var err error
+		if c, err = draw.NewFormattedCanvas(w, h, format); err != nil {
+			return err
+		}
+	}
+	rows := make([][]*plot.Plot, len(panels))
+	for i, p := range panels {
+		rows[i] = []*plot.Plot{p}
+	}
+	tiles := draw.Tiles{Rows: len(panels), Cols: 1, PadTop: vg.Millimeter * 2, PadBottom: vg.Millimeter * 2, PadLeft: vg.Millimeter * 2, PadRight: vg.Millimeter * 4, PadY: vg.Millimeter * 4}
+	canvases := plot.Align(rows, tiles, draw.New(c))
+	for i, p := range panels {
+		p.Draw(canvases[i][0])
+	}
+	fo, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := c.WriteTo(fo); err != nil {
+		fo.Close()
+		return err
+	}
+	return fo.Close()
+}
+
+// pointSink keeps the points written to it in memory.
+type pointSink struct {
+	xySlices
+}
+
+func (s *pointSink) write(x, y float64) error {
+	s.x, s.y = append(s.x, x), append(s.y, y)
+	return nil
+}
+
+func (s *pointSink) Close() error { return nil }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestComparisonPlots(t *testing.T) {
+	defer func(overlay, residual, band bool, tol, w, h, dpi float64) {
+		plotOverlay, plotResidual, plotBand, tolerance = overlay, residual, band, tol
+		plotWidthPx, plotHeightPx, plotDPI = w, h, dpi
+	}(plotOverlay, plotResidual, plotBand, tolerance, plotWidthPx, plotHeightPx, plotDPI)
+	plotWidthPx, plotHeightPx, plotDPI = 400, 600, 96
+	tolerance = 0.5
+	orig := &xySlices{}
+	for i := 0; i < 200; i++ {
+		x, y := float64(i)/10, math.Sin(float64(i)/10)
+		if i == 100 {
+			y = math.NaN()
+		}
+		orig.x, orig.y = append(orig.x, x), append(orig.y, y)
+	}
+	// Decimated data through every original point has no residual,
+	// so the residual panel spans the tolerance band only when drawn.
+	dec := &xySlices{x: orig.x, y: orig.y}
+	for _, test := range []struct {
+		overlay, residual, band bool
+		panels                  int
+	}{
+		{overlay: true, residual: false, panels: 1},
+		{overlay: false, residual: false, panels: 2},
+		{overlay: true, residual: true, panels: 2},
+		{overlay: true, residual: true, band: true, panels: 2},
+		{overlay: false, residual: true, band: true, panels: 3},
+	} {
+		plotOverlay, plotResidual, plotBand = test.overlay, test.residual, test.band
+		panels, err := comparisonPlots(orig, dec, "t", "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(panels) != test.panels {
+			t.Fatalf("%+v: got %d panels, want %d", test, len(panels), test.panels)
+		}
+		top := panels[0]
+		for i, p := range panels[1:] {
+			if p.X.Min != top.X.Min || p.X.Max != top.X.Max {
+				t.Errorf("%+v: panel %d x range [%g, %g], want [%g, %g]", test, i+1, p.X.Min, p.X.Max, top.X.Min, top.X.Max)
+			}
+		}
+		if test.residual {
+			res := panels[len(panels)-1]
+			if res.Y.Label.Text != "residual" {
+				t.Errorf("%+v: last panel is %q, want residual", test, res.Y.Label.Text)
+			}
+			wantMin, wantMax := 0.0, 0.0
+			if test.band {
+				wantMin, wantMax = -tolerance, tolerance
+			}
+			if res.Y.Min != wantMin || res.Y.Max != wantMax {
+				t.Errorf("%+v: residual y range [%g, %g], want [%g, %g]", test, res.Y.Min, res.Y.Max, wantMin, wantMax)
+			}
+		}
+		if err := saveImage(filepath.Join(t.TempDir(), "a.svg"), "svg", panels); err != nil {
+			t.Fatalf("%+v: %s", test, err)
+		}
+	}
+}
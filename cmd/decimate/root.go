@@ -1,21 +1,29 @@
 package main
 
 import (
-	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 // flags
 var tolerance float64 = 0.1 // default for tests
-var xFlag, yFlag, inputSeparator, outputName, outputDir, outputExtension, floatFormat string
+var xFlag, yFlag, inputSeparator, outputName, outputDir, floatFormat, xFormat string
 var interp, enforceComma, silent, noHeader bool
+var columnToleranceFlag string
+
+// columnTolerances holds the tolerances of y columns given by name or number.
+var columnTolerances map[string]float64
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -27,6 +35,19 @@ Generates decimated files from a token separated
 file for use in plotting tools. Column numbering
 starts at 1.
 
+Input and output formats are chosen by file extension:
+NumPy .npy (2-D arrays) and .npz, raw binary .bin,
+.f32 and .f64 files (see --ncols, --dtype, --endian and
+--layout), MATLAB .mat files (Level 5 vectors and
+matrices), LTspice and ngspice .raw files and WAV audio
+(input only), SVG paths (output only, see --width,
+--height and --axes) and delimited text for any other
+extension. Files may be compressed with gzip (.gz),
+zstd (.zst) or xz (.xz). Compressed input is detected
+by content and output is compressed by extension.
+WAV columns are "time" in seconds and "ch1", "ch2"...
+Columns of binary formats are named by their number.
+
 Examples:
 
 	decimate -x time -y "x,y,z" myFile.csv
@@ -38,147 +59,333 @@ named 'x', 'y' and 'z'.
 
 Operates on the time x-column and all y-columns of a
 tab separated file. 
+
+	decimate -x timestamp -y value --xformat time log.csv
+
+Operates on a file with ISO-8601 timestamps as x-column
+without losing nanosecond precision.
+
+	decimate -x time -y "*" --height 8cm --dpi 300 data.csv
+
+Chooses each column's tolerance so that the decimated data
+is indistinguishable from the original on an 8cm tall figure
+printed at 300 DPI.
+
+	decimate -x time -y "*" --width 800 --height 300 --axes -o fig.svg data.csv
+
+Draws each column as an SVG figure for web reports.
+
+	decimate -x time -y "*" -o small.csv.zst log.csv.gz
+
+Decimates a gzip compressed log into zstd compressed
+files without decompressing to disk.
+
+	ssh host cat log.csv | decimate -x time -y "a,b" --mux wide - | less
+
+Reads from stdin when the input filename is "-" and writes
+all columns to stdout. Data read from stdin or files without
+extension are told by content. Raw binary needs --input-format.
+
+	decimate -x time -y "*" --pgfplots -t 1e-3 data.csv
+
+Writes data-<ycol>.dat tables and a data.tex snippet to
+\input in a LaTeX document which loads pgfplots.
+
+	decimate -x time -y "*" --emit-script python --script-original data.csv
+
+Writes data.py next to the output which plots the
+decimated columns over the original ones.
+
+	decimate -x time -y "*" --outdir small -o .npy "runs/*.csv" extra.csv
+
+Decimates several files at once into the small directory,
+writing <input>-<ycol>.npy files. Files are processed
+concurrently (see --concurrency) and failures are listed
+in a summary at the end instead of stopping the batch.
+
+	decimate --job runs.yaml -t 0.05
+
+Runs the jobs declared in runs.yaml, each with its own inputs
+and flag values keyed by long flag name, here with a tolerance
+of 0.05 for all of them. Keys outside the jobs list are shared
+by all jobs and paths are relative to the job file:
+
+	delimiter: ","
+	xcol: time
+	jobs:
+	  - name: scope
+	    inputs: [scope/*.csv]
+	    ycols: [ch1, ch2]
+	    column-tolerance: {ch1: 0.01, ch2: 0.5}
+	    outdir: small
+	  - inputs: sim.raw
+	    ycols: "V(out)"
+	    interp: true
+
+	decimate -x time -y "*" --algorithm lttb --points 2000 data.csv
+
+Keeps 2000 points per column chosen by an algorithm other than
+the default. Algorithms and their parameters are:
+
+	rolling-x         keeps points so that data stays within
+	                  --tolerance, reading input once, and at
+	                  most --max-gap of x apart if set (default)
+	rolling-x-interp  as rolling-x moving points to cut more
+	                  of them, same as --interp
+	swinging-door     swinging door compression keeping data
+	                  within twice --deviation, by default half
+	                  of --tolerance, and at most --window of x
+	                  apart if set
+	rdp               Ramer-Douglas-Peucker within --tolerance
+	min-segments      fewest segments within --tolerance with
+	                  vertices at input x, not restricted to input y
+	visvalingam       Visvalingam-Whyatt keeping --points
+	lttb              Largest-Triangle-Three-Buckets keeping --points
+	m4                first, last, min and max points of each of
+	                  --width pixel columns
+
+rdp, min-segments, visvalingam, lttb and m4 hold each column
+in memory and write it once input ends, so they can't be used
+with --mux wide. --height sets the tolerance of those which
+use one. Columns split by NaN gaps are simplified a segment
+at a time and --points is shared among segments, each keeping
+at least 2 points with visvalingam and 3 with lttb, so columns
+with many gaps may keep more than --points.
+
+	decimate -x 1 -y "*" --ncols 4 -o out.npy capture.f32
+
+Reads 4 interleaved float32 columns and writes a
+2-column .npy file per y column.
+
+	decimate -x time -y "V(out),I(R1)" --step 2 sim.raw
+
+Reads two traces of the second stepped run of a SPICE
+simulation. Output is written as CSV. AC analysis traces
+are magnitudes, see also "re(V(out))", "im(V(out))" and
+"ph(V(out))" columns.
 `,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if jobFile != "" {
+			if len(args) > 0 {
+				return errors.New("inputs of a job file are given by its inputs key")
+			}
+			var err error
+			jobs, err = readJobFile(jobFile, cmd.Flags())
+			return err
+		}
 		if err := checkParameters(args); err != nil {
 			return err
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := run(args); err != nil {
-			fmt.Printf("[ERR] %s", err)
+		if jobFile != "" {
+			if err := runJobs(cmd.Flags(), jobs); err != nil {
+				os.Exit(1)
+			}
+			return
+		}
+		if err := runInputs(); err != nil {
+			if len(inputs) == 1 {
+				fmt.Fprintf(logOut, "[ERR] %s\n", err)
+			}
 			os.Exit(1)
 		}
 	},
 }
 
+// jobs holds the jobs read from a job file.
+var jobs []jobSpec
+
+// runInputs decimates the inputs checked by checkParameters. Errors of
+// several inputs are printed as they are summarized.
+func runInputs() error {
+	if len(inputs) > 1 {
+		return runBatch(inputs)
+	}
+	return run(inputs[0])
+}
+
 type job struct {
-	*csv.Writer
+	sink
+	*task
 	xname, yname string
 	tolerance    float64
 	stepper
 }
 
+// process steps the job's algorithm with a point and writes
+// the decimated point it yields, if any.
+func (j *job) process(x, y float64) error {
+	j.stepper = j.step(x, y)
+	if m, ok := j.stepper.(multiStepper); ok {
+		xs, ys, err := m.pending()
+		for i := 0; i < len(xs) && err == nil; i++ {
+			err = j.write(xs[i], ys[i])
+		}
+		return err
+	}
+	if j.stepper.ready() {
+		return j.write(j.stepper.xy())
+	}
+	return nil
+}
+
 const badFilenameChar = "/\\:*?\"><|"
 
 func getJobName(j job) string {
 	sanitizedYname := replaceCutset(j.yname, badFilenameChar, "-")
-	return filepath.Join(outputDir, outputName+"-"+sanitizedYname+"."+outputExtension)
+	name := j.name + "-" + sanitizedYname + "." + j.ext
+	if j.compression != "" {
+		name += "." + j.compression
+	}
+	return filepath.Join(j.dir, name)
 }
 
-func run(args []string) error {
-	fi, err := os.Open(args[0])
-	if err != nil {
-		return fmt.Errorf("error opening file")
+func run(t *task) (err error) {
+	xc := newXCodec(xFormat)
+	input := t.input
+	if input == "-" && heightPx > 0 {
+		// plot size tolerances need two passes over input.
+		if input, err = spoolStdin(); err != nil {
+			return err
+		}
+		defer os.Remove(input)
 	}
-	rdr := csv.NewReader(fi)
-	rdr.Comma = rune(inputSeparator[0])
-	rdr.TrimLeadingSpace = true
-	headers, err := rdr.Read()
+	src, err := openSource(input, xc)
 	if err != nil {
 		return err
 	}
-	if findNumerical(headers) >= 0 {
-		return fmt.Errorf("numerical header entry found: %s", headers[findNumerical(headers)])
-	}
+	defer src.Close()
+	headers := src.header()
 	var yColNames []string
-	if yColNames, err = parseHeader(headers); err != nil {
+	if t.xname, yColNames, err = parseHeader(headers); err != nil {
 		return err
 	}
 
 	var yxIdx []int
-	for _, v := range append(yColNames, xFlag) {
+	for _, v := range append(yColNames, t.xname) {
 		i := findStringInSlice(v, headers)
 		if i < 0 {
 			return fmt.Errorf("%s is not in columns:\n%v", v, headers)
 		}
 		yxIdx = append(yxIdx, i)
 	}
+	tols := make([]float64, len(yColNames))
+	if heightPx > 0 {
+		// tolerance derived from plot size.
+		if tols, err = viewportTolerances(input, yxIdx); err != nil {
+			return err
+		}
+	} else {
+		for i := range tols {
+			tols[i] = tolerance
+		}
+	}
+	colTols, err := yColumnTolerances(headers, yColNames)
+	if err != nil {
+		return err
+	}
+	for i, tol := range colTols {
+		tols[i] = tol
+	}
 	// we have as many files to create as y columns given
 	var jobs []*job
-	for i := 0; i < len(yColNames); i++ {
-		var algorithm stepper
-		if interp {
-			algorithm = interpStepper{}
-		} else {
-			algorithm = inPlaceStepper{}
+	defer func() {
+		for _, j := range jobs {
+			if cerr := j.Close(); err == nil {
+				err = cerr
+			}
 		}
-		j := job{
-			xname:     xFlag,
-			yname:     yColNames[i],
-			tolerance: tolerance,
-			stepper:   algorithm,
+		if err == nil && pgfplots {
+			err = writePgfplotsTeX(t, jobs)
+		}
+		if err == nil && emitScript != "" {
+			err = writeScript(t, yxIdx, jobs)
 		}
-		fo, err := os.Create(getJobName(j))
-		defer fo.Close()
-		if err != nil {
+	}()
+	if muxMode == "" && t.dir != "" {
+		if err = os.MkdirAll(t.dir, 0755); err != nil {
 			return err
 		}
-		j.Writer = csv.NewWriter(fo)
-		defer j.Flush()
-		if !enforceComma {
-			j.Writer.Comma = rune(inputSeparator[0])
+	}
+	var mw *muxWriter
+	if muxMode != "" {
+		if mw, err = newMuxWriter(os.Stdout, t.xname, yColNames, xc); err != nil {
+			return err
 		}
-		if !noHeader {
-			err = j.Write([]string{j.xname, j.yname})
+	}
+	for i := 0; i < len(yColNames); i++ {
+		j := job{
+			task:      t,
+			xname:     t.xname,
+			yname:     yColNames[i],
+			tolerance: tols[i],
+			stepper:   newStepper(tols[i]),
 		}
-		if err != nil {
-			return err
+		if mw != nil {
+			j.sink = muxSink{m: mw, col: i}
+			alert("writing %s to stdout with tolerance %g", j.yname, j.tolerance)
+		} else {
+			if j.sink, err = createSink(getJobName(j), j.xname, j.yname, j.tolerance, xc); err != nil {
+				return err
+			}
+			alert("creating file %s with tolerance %g", getJobName(j), j.tolerance)
 		}
-		alert("creating file %s", getJobName(j))
 		jobs = append(jobs, &j)
 	}
 	// begin doing the heavy lifting
+	if mw == nil && len(jobs) > 1 {
+		// multiplexed output is written in row order so it stays sequential.
+		return runParallel(src, yxIdx, jobs)
+	}
+	return runSequential(src, yxIdx, jobs)
+}
+
+// runSequential reads all rows of src and processes the jobs in turn
+// for each row.
+func runSequential(src source, yxIdx []int, jobs []*job) error {
+	row := make([]float64, len(yxIdx))
 	var EOF bool
 	for !EOF {
-		record, err := rdr.Read()
-		if err != nil {
-			if err.Error() == "EOF" {
-				alert("finished writing files")
-				EOF = true
-				record = NaNslice(len(headers))
-			} else {
-				return err
+		if err := src.read(yxIdx, row); errors.Is(err, io.EOF) {
+			alert("finished writing files")
+			EOF = true
+			for i := range row {
+				row[i] = math.NaN()
 			}
-		}
-		x, err := strconv.ParseFloat(record[yxIdx[len(yxIdx)-1]], 64)
-		if err != nil {
+		} else if err != nil {
 			return err
 		}
-		for i := 0; i < len(yColNames); i++ {
-			y, err := strconv.ParseFloat(record[yxIdx[i]], 64)
-			if err != nil {
+		x := row[len(row)-1]
+		for i := range jobs {
+			if err := jobs[i].process(x, row[i]); err != nil {
 				return err
 			}
-			jobs[i].stepper = jobs[i].step(x, y)
-			if jobs[i].stepper.ready() {
-				if err := jobs[i].Write(jobs[i].stepper.values(floatFormat)); err != nil {
-					panic(err)
-				}
-			}
 		}
 	}
-
 	return nil
 }
 
-func parseHeader(headers []string) ([]string, error) {
+// parseHeader returns the names of the x and y columns
+// given by flags, which may be column numbers.
+func parseHeader(headers []string) (string, []string, error) {
 	yColsSplit := splitColumns(yFlag)
+	xname := xFlag
 	// Column number replacer
 	if colNum, err := strconv.Atoi(xFlag); err == nil && colNum > 0 {
 		if colNum > len(headers) || colNum == 0 {
-			return nil, fmt.Errorf("x column number %d too large or zero. Have %d headers", colNum, len(headers))
+			return "", nil, fmt.Errorf("x column number %d too large or zero. Have %d headers", colNum, len(headers))
 		}
-		xFlag = headers[colNum-1]
+		xname = headers[colNum-1]
 	}
 	for _, h := range headers {
-		if h != xFlag {
+		if h != xname {
 			for i, y := range yColsSplit {
 				colNum, err := strconv.Atoi(y)
 				if err == nil && colNum > 0 {
 					if colNum > len(headers) || colNum == 0 {
-						return nil, fmt.Errorf("y column number %d too large or zero. Have %d headers", colNum, len(headers))
+						return "", nil, fmt.Errorf("y column number %d too large or zero. Have %d headers", colNum, len(headers))
 					}
 					yColsSplit[i] = headers[colNum-1]
 				}
@@ -189,19 +396,38 @@ func parseHeader(headers []string) ([]string, error) {
 	if yColsSplit[0] == "*" && len(yColsSplit) == 1 {
 		yColsSplit = []string{}
 		for _, h := range headers {
-			if h != xFlag {
+			if h != xname {
 				yColsSplit = append(yColsSplit, h)
 			}
 		}
 	}
-	return yColsSplit, nil
+	return xname, yColsSplit, nil
 }
 
-func NaNslice(n int) (nans []string) {
-	for i := 0; i < n; i++ {
-		nans = append(nans, "NaN")
+// yColumnTolerances returns the tolerances given by --column-tolerance
+// by index in yColNames. Columns are named or numbered as with -y.
+func yColumnTolerances(headers, yColNames []string) (map[int]float64, error) {
+	keys := make([]string, 0, len(columnTolerances))
+	for k := range columnTolerances {
+		keys = append(keys, k)
 	}
-	return nans
+	sort.Strings(keys)
+	tols := make(map[int]float64, len(keys))
+	for _, k := range keys {
+		name := k
+		if colNum, err := strconv.Atoi(k); err == nil && colNum > 0 {
+			if colNum > len(headers) {
+				return nil, fmt.Errorf("column tolerance column number %d too large. Have %d headers", colNum, len(headers))
+			}
+			name = headers[colNum-1]
+		}
+		i := findStringInSlice(name, yColNames)
+		if i < 0 {
+			return nil, fmt.Errorf("column tolerance given for %s which is not a y column:\n%v", k, yColNames)
+		}
+		tols[i] = columnTolerances[k]
+	}
+	return tols, nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -215,13 +441,27 @@ func Execute() {
 
 // actually modifies flag values!
 func checkParameters(args []string) error {
-	// filename
-	if len(args) != 1 {
-		fmt.Printf("args: %v", args)
-		return errors.New("requires exactly one argument as input filename")
+	// filenames
+	if len(args) < 1 {
+		return errors.New("requires at least one argument as input filename")
 	}
-	if _, err := os.Stat(args[0]); err != nil {
-		return fmt.Errorf("opening %s. %s", args[0], err)
+	paths, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+	if len(paths) > 1 {
+		if findStringInSlice("-", paths) >= 0 {
+			return errors.New("stdin input \"-\" can't be used with other inputs")
+		}
+		if muxMode != "" {
+			return errors.New("--mux needs a single input")
+		}
+		if name, _ := splitFileExtension(discardPath(outputName)); name != "" && !strings.HasPrefix(name, "<inputName>") {
+			return errors.New("--output can't name the output of several inputs. use --outdir and, to set the format, an extension such as -o .npy")
+		}
+	}
+	if xFlag == "" {
+		return errors.New("found no x-column flag value")
 	}
 	// y columns
 	ycols := splitColumns(yFlag)
@@ -238,37 +478,132 @@ func checkParameters(args []string) error {
 	if len(inputSeparator) != 1 {
 		return errors.New("delimiter should be one character. '\\t' and 'tab' work as an option")
 	}
-	var iname string
-	if strings.Contains(outputName, string(filepath.Separator)) || strings.Contains(outputName, "/") {
-		outputDir = filepath.Dir(outputName)
-		outputName = discardPath(outputName)
+	columnTolerances = nil
+	if columnToleranceFlag != "" {
+		columnTolerances = make(map[string]float64)
+		for _, kv := range strings.Split(columnToleranceFlag, inputSeparator) {
+			i := strings.LastIndex(kv, "=")
+			if i < 0 {
+				return fmt.Errorf("column tolerance %q should be of the form column=tolerance", kv)
+			}
+			tol, err := strconv.ParseFloat(kv[i+1:], 64)
+			if err != nil || !(tol >= 0) {
+				return fmt.Errorf("bad tolerance of column %q: %q", kv[:i], kv[i+1:])
+			}
+			columnTolerances[kv[:i]] = tol
+		}
+	}
+	if concurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
 	}
-	outputName, outputExtension = splitFileExtension(outputName)
-	if outputExtension == "" || outputExtension == "<inputExtension>" {
-		iname, outputExtension = splitFileExtension(discardPath(args[0]))
-		if outputName == "<inputName>-<ycol>" || outputName == "" {
-			outputName = iname
+	if inputFormat != "" && findStringInSlice(inputFormat, inputFormats) < 0 {
+		return fmt.Errorf("unknown --input-format %q. want one of %s", inputFormat, strings.Join(inputFormats, ", "))
+	}
+	// multiplexed output to stdout
+	switch muxMode {
+	case "":
+		logOut = os.Stdout
+	case muxTagged, muxWide:
+		if outputName != "" || pgfplots || emitScript != "" {
+			return errors.New("--mux writes to stdout and can't be used with --output, --pgfplots or --emit-script")
 		}
+		logOut = os.Stderr
+	default:
+		return fmt.Errorf("unknown --mux %q. want tagged or wide", muxMode)
+	}
+	// x column format. Layouts that format to themselves are not layouts at all.
+	switch xFormat {
+	case "float", "int", "time", "rfc3339", "iso8601":
+	default:
+		if time.Unix(0, 0).Format(xFormat) == xFormat {
+			return fmt.Errorf("x format %q is not one of float, int, time or a Go time layout", xFormat)
+		}
+	}
+	// viewport
+	heightPx, widthPx = 0, 0
+	if heightFlag != "" {
+		if heightPx, err = parseLength(heightFlag, dpi); err != nil {
+			return err
+		}
+	}
+	if widthFlag != "" {
+		if widthPx, err = parseLength(widthFlag, dpi); err != nil {
+			return err
+		}
+	}
+	if err := checkAlgorithm(); err != nil {
+		return err
+	}
+	// raw binary format
+	if binDtype != "float64" && binDtype != "float32" {
+		return fmt.Errorf("unknown --dtype %q. want float32 or float64", binDtype)
+	}
+	if binEndian != "little" && binEndian != "big" {
+		return fmt.Errorf("unknown --endian %q. want little or big", binEndian)
+	}
+	if binLayout != "interleaved" && binLayout != "columns" {
+		return fmt.Errorf("unknown --layout %q. want interleaved or columns", binLayout)
+	}
+	if pgfplots && xFormat != "float" && xFormat != "int" {
+		return errors.New("--pgfplots needs a numeric x column format")
+	}
+	if spiceStep < 1 {
+		return errors.New("--step starts at 1")
 	}
 	// formatter
 	const floatNum = .125
 	if _, err := strconv.ParseFloat(fmt.Sprintf(floatFormat, floatNum), 64); err != nil {
 		return errors.New("formatting option yielded error. example of usage: \n'%0.2f' for two decimal placed\n'%e' for scientific notation\nError: " + err.Error())
 	}
+	// inputs. Errors of a single input are reported at once
+	// while those of several are left for the batch summary.
+	inputs = inputs[:0]
+	for _, path := range paths {
+		t, err := newTask(path)
+		if err != nil && len(paths) == 1 {
+			return err
+		} else if err != nil {
+			t = &task{input: path, err: err}
+		}
+		inputs = append(inputs, t)
+	}
+	checkCollisions(inputs)
 	return nil
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&outputName, "output", "o", "", "Output filename. Named after ycolumn. Extension by default is input file's")
+	rootCmd.Flags().StringVarP(&outputName, "output", "o", "", "Output filename. Named after ycolumn. Extension by default is input file's. With several inputs only a directory and extension may be given, as in -o out/.npy")
+	rootCmd.Flags().StringVar(&outputDir, "outdir", "", "Output directory. Outputs of several inputs are named after each input")
+	rootCmd.Flags().IntVar(&concurrency, "concurrency", runtime.NumCPU(), "Number of inputs processed at once")
 	rootCmd.Flags().StringVarP(&floatFormat, "fformat", "f", "%.6e", "Floating point format")
 	rootCmd.Flags().BoolVarP(&enforceComma, "comma", "c", false, "Force output to use comma as delimiter")
 	rootCmd.Flags().StringVarP(&inputSeparator, "delimiter", "d", ",", "Delimiter token. Examples: '-d \\t' or '-d=\";\"'")
 	rootCmd.Flags().Float64VarP(&tolerance, "tolerance", "t", 0.1, "Downsampling y-value tolerance.")
+	rootCmd.Flags().StringVar(&columnToleranceFlag, "column-tolerance", "", "Per column tolerances overriding --tolerance and --height, separated by delimiter. Columns are named or numbered as with -y. Example: 'a=0.01,3=0.5'")
+	rootCmd.Flags().StringVar(&jobFile, "job", "", "YAML, TOML or JSON file declaring jobs with inputs and flag values. Flags given on the command line override the file")
 	rootCmd.Flags().StringVarP(&yFlag, "ycols", "y", "", "Y column names/numbers separated by delimiter. Numbering starts at 1. Pass -y=\"*\" to process all columns (required)")
-	_ = rootCmd.MarkFlagRequired("ycols")
 	rootCmd.Flags().StringVarP(&xFlag, "xcol", "x", "", "X column name. May be column number starting at 1. (required)")
-	_ = rootCmd.MarkFlagRequired("xcol")
-	rootCmd.Flags().BoolVarP(&interp, "interp", "i", false, "Use more aggressive interpolating algorithm. Changes y values")
+	rootCmd.Flags().StringVar(&xFormat, "xformat", "float", "X column format: 'float', 'int' for integer timestamps such as Unix nanoseconds, 'time' for ISO-8601 timestamps or a Go time layout. Output keeps the input format and must be delimited text unless float")
+	rootCmd.Flags().BoolVarP(&interp, "interp", "i", false, "Use more aggressive interpolating algorithm. Changes y values. Same as --algorithm rolling-x-interp")
+	rootCmd.Flags().StringVar(&algorithm, "algorithm", "rolling-x", "Downsampling algorithm: "+strings.Join(algorithms, ", ")+". See help for their parameters")
+	rootCmd.Flags().IntVar(&algorithmPoints, "points", 0, "Number of points per column kept by lttb and visvalingam. Segments between NaN gaps keep at least 3 points with lttb and 2 with visvalingam")
+	rootCmd.Flags().Float64Var(&maxGap, "max-gap", 0, "Max x distance between points written by rolling-x. Zero for no limit")
+	rootCmd.Flags().Float64Var(&deviation, "deviation", 0, "Compression deviation of swinging-door, which keeps data within twice of it. Zero for half of each column's tolerance")
+	rootCmd.Flags().Float64Var(&doorWindow, "window", 0, "Compression window of swinging-door, the max x distance between points written. Zero for no limit")
+	rootCmd.Flags().StringVar(&heightFlag, "height", "", "Plot height in pixels or physical units (cm, mm, in) with --dpi. If set, tolerance is derived per column so error stays below half a pixel")
+	rootCmd.Flags().StringVar(&widthFlag, "width", "", "Plot width in pixels or physical units (cm, mm, in) with --dpi. Sets .svg output width and the buckets of m4. Tolerances bound vertical error and are derived from --height alone")
+	rootCmd.Flags().BoolVar(&pgfplots, "pgfplots", false, "Write whitespace separated .dat tables and a .tex snippet with an \\addplot per y column")
+	rootCmd.Flags().StringVar(&emitScript, "emit-script", "", "Write a gnuplot or python (matplotlib) script plotting the output files")
+	rootCmd.Flags().BoolVar(&scriptOriginal, "script-original", false, "Also plot the original input in the --emit-script script for comparison")
+	rootCmd.Flags().BoolVar(&svgAxes, "axes", false, "Draw axes around .svg output")
+	rootCmd.Flags().Float64Var(&dpi, "dpi", 0, "Dots per inch for --width and --height given in physical units")
+	rootCmd.Flags().StringVar(&binDtype, "dtype", "float64", "Raw binary (.bin) value type: float32 or float64. Extensions .f32 and .f64 set it")
+	rootCmd.Flags().StringVar(&binEndian, "endian", "little", "Raw binary byte order: little or big")
+	rootCmd.Flags().IntVar(&binColumns, "ncols", 0, "Number of columns of raw binary input")
+	rootCmd.Flags().StringVar(&binLayout, "layout", "interleaved", "Raw binary layout: interleaved rows or columns stored one after the other")
+	rootCmd.Flags().IntVar(&spiceStep, "step", 1, "Stepped run of SPICE .raw input to read, starting at 1")
+	rootCmd.Flags().StringVar(&inputFormat, "input-format", "", "Input data format, overriding the file extension: "+strings.Join(inputFormats, ", ")+". Extensionless files and stdin are detected by content")
+	rootCmd.Flags().StringVar(&muxMode, "mux", "", "Write all y columns to stdout as one stream: 'tagged' rows of column name, x and y or 'wide' rows of x and one cell per column. Messages go to stderr")
 	rootCmd.Flags().BoolVarP(&silent, "silent", "s", false, "Silent execution (no printing).")
 	rootCmd.Flags().BoolVarP(&noHeader, "headerless", "n", false, "If set does not print headers in new file.")
 }
@@ -311,7 +646,7 @@ func alert(format string, args ...interface{}) {
 		if args == nil {
 			msg = fmt.Sprintf(format)
 		}
-		fmt.Print("[INFO] ", msg, "\n")
+		fmt.Fprint(logOut, "[INFO] ", msg, "\n")
 	}
 }
 
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestYColumnTolerances(t *testing.T) {
+	defer func() { columnTolerances = nil }()
+	headers := []string{"t", "a", "b", "c"}
+	yColNames := []string{"a", "c"}
+
+	columnTolerances = map[string]float64{"a": 0.5, "4": 0.25}
+	tols, err := yColumnTolerances(headers, yColNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tols) != 2 || tols[0] != 0.5 || tols[1] != 0.25 {
+		t.Errorf("got tolerances %v by y column index", tols)
+	}
+
+	for _, key := range []string{"bb", "b", "1", "5"} {
+		columnTolerances = map[string]float64{key: 0.5}
+		if _, err := yColumnTolerances(headers, yColNames); err == nil {
+			t.Errorf("expected error for column tolerance key %q", key)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// script flags
+var emitScript string
+var scriptOriginal bool
+
+// checkScriptParameters validates script flags once output and input formats of t are known.
+func checkScriptParameters(t *task) error {
+	switch emitScript {
+	case "":
+		if scriptOriginal {
+			return errors.New("--script-original requires --emit-script")
+		}
+		return nil
+	case "gnuplot", "python":
+	default:
+		return fmt.Errorf("unknown --emit-script %q. want gnuplot or python", emitScript)
+	}
+	if t.compression != "" {
+		return errors.New("--emit-script needs uncompressed output")
+	}
+	if !pgfplots && formatFromExtension(t.ext) != formatCSV {
+		return errors.New("--emit-script needs delimited text output")
+	}
+	if xFormat != "float" && xFormat != "int" {
+		return errors.New("--emit-script needs a numeric x column format")
+	}
+	if scriptOriginal && t.input == "-" {
+		return errors.New("--script-original needs a file input")
+	}
+	if scriptOriginal && formatFromExtension(dataExtension(t.input)) != formatCSV {
+		return errors.New("--script-original needs delimited text input")
+	}
+	// gnuplot's separator applies to all files plotted.
+	if emitScript == "gnuplot" && scriptOriginal && outputSeparator() != rune(inputSeparator[0]) {
+		return errors.New("gnuplot --script-original needs output delimited as input is")
+	}
+	return nil
+}
+
+// outputSeparator returns the delimiter of text output or 0 for whitespace.
+func outputSeparator() rune {
+	switch {
+	case pgfplots:
+		return 0
+	case enforceComma:
+		return ','
+	}
+	return rune(inputSeparator[0])
+}
+
+// table is a delimited text file plotted by a script.
+type table struct {
+	path, title string
+	// 0 based x and y column numbers.
+	xcol, ycol int
+	// sep is the delimiter or 0 for whitespace.
+	sep    rune
+	header bool
+}
+
+// writeScript writes a gnuplot or Python script next to the output files
+// which plots the jobs' output and optionally the original input columns yxIdx.
+func writeScript(t *task, yxIdx []int, jobs []*job) error {
+	ext := ".gp"
+	if emitScript == "python" {
+		ext = ".py"
+	}
+	path := filepath.Join(t.dir, t.name+ext)
+	outSep := outputSeparator()
+	var tables []table
+	for i, j := range jobs {
+		if scriptOriginal {
+			rel, err := relativePath(t.dir, t.input)
+			if err != nil {
+				return err
+			}
+			tables = append(tables, table{path: rel, title: j.yname + " (original)", xcol: yxIdx[len(yxIdx)-1], ycol: yxIdx[i], sep: rune(inputSeparator[0]), header: true})
+		}
+		rel, err := relativePath(t.dir, getJobName(*j))
+		if err != nil {
+			return err
+		}
+		tables = append(tables, table{path: rel, title: j.yname, xcol: 0, ycol: 1, sep: outSep, header: !noHeader})
+	}
+	var script string
+	if emitScript == "python" {
+		script = pythonScript(t.xname, tables)
+	} else {
+		script = gnuplotScript(t.xname, tables)
+	}
+	alert("creating file %s", path)
+	return ioutil.WriteFile(path, []byte(script), 0644)
+}
+
+// relativePath returns path relative to the output directory dir, where scripts are written.
+func relativePath(dir, path string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(dir, abs)
+	return filepath.ToSlash(rel), err
+}
+
+func gnuplotScript(xname string, tables []table) string {
+	var b strings.Builder
+	b.WriteString("# Generated by decimate. Run from this file's directory with: gnuplot -p <script>\n")
+	sep := "whitespace"
+	switch tables[0].sep {
+	case 0:
+	case '\t':
+		sep = "tab"
+	default:
+		sep = gnuplotQuote(string(tables[0].sep))
+	}
+	b.WriteString("set encoding utf8\n")
+	fmt.Fprintf(&b, "set datafile separator %s\n", sep)
+	b.WriteString("set key outside noenhanced\n")
+	fmt.Fprintf(&b, "set xlabel %s noenhanced\n", gnuplotQuote(xname))
+	b.WriteString("plot")
+	for i, t := range tables {
+		if i > 0 {
+			b.WriteString(", \\\n    ")
+		} else {
+			b.WriteByte(' ')
+		}
+		every := ""
+		if t.header {
+			every = " every ::1"
+		}
+		fmt.Fprintf(&b, "%s using %d:%d%s with lines title %s", gnuplotQuote(t.path), t.xcol+1, t.ycol+1, every, gnuplotQuote(t.title))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func pythonScript(xname string, tables []table) string {
+	var b strings.Builder
+	b.WriteString("# Generated by decimate.\n")
+	b.WriteString("import os\n\nimport matplotlib.pyplot as plt\nimport numpy as np\n\n")
+	b.WriteString("here = os.path.dirname(os.path.abspath(__file__))\n")
+	b.WriteString("tables = [\n")
+	b.WriteString("    # path, legend, x column, y column, delimiter, header rows\n")
+	for _, t := range tables {
+		sep := "None"
+		if t.sep != 0 {
+			sep = pythonQuote(string(t.sep))
+		}
+		header := 0
+		if t.header {
+			header = 1
+		}
+		fmt.Fprintf(&b, "    (%s, %s, %d, %d, %s, %d),\n", pythonQuote(t.path), pythonQuote(t.title), t.xcol, t.ycol, sep, header)
+	}
+	b.WriteString("]\n")
+	b.WriteString("for path, label, xcol, ycol, delimiter, header in tables:\n")
+	b.WriteString("    data = np.genfromtxt(os.path.join(here, path), delimiter=delimiter, skip_header=header, usecols=(xcol, ycol), ndmin=2)\n")
+	b.WriteString("    plt.plot(data[:, 0], data[:, 1], label=label)\n")
+	fmt.Fprintf(&b, "plt.xlabel(%s)\n", pythonQuote(xname))
+	b.WriteString("plt.legend()\nplt.show()\n")
+	return b.String()
+}
+
+// gnuplotQuote returns s as a gnuplot single quoted string, in which
+// only quotes are escaped, by doubling them. Line breaks are replaced
+// by spaces since strings can't span lines.
+func gnuplotQuote(s string) string {
+	s = strings.NewReplacer("'", "''", "\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+	return "'" + s + "'"
+}
+
+// pythonQuote returns s as a Python string literal. Characters other
+// than control characters are kept as is since Python 3 reads source
+// files as UTF-8.
+func pythonQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '"':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
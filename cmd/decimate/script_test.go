@@ -0,0 +1,22 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptQuoting(t *testing.T) {
+	tables := []table{{path: "data-température.csv", title: `it's "ΔV"`, xcol: 0, ycol: 1, sep: '\t', header: true}}
+	gp := gnuplotScript("tiempo (µs)", tables)
+	for _, want := range []string{"set encoding utf8\n", "set datafile separator tab\n", "set xlabel 'tiempo (µs)' noenhanced", `'data-température.csv' using 1:2 every ::1 with lines title 'it''s "ΔV"'`} {
+		if !strings.Contains(gp, want) {
+			t.Errorf("gnuplot script missing %q:\n%s", want, gp)
+		}
+	}
+	py := pythonScript("tiempo (µs)", tables)
+	for _, want := range []string{`plt.xlabel("tiempo (µs)")`, `("data-température.csv", "it's \"ΔV\"", 0, 1, "\x09", 1),`} {
+		if !strings.Contains(py, want) {
+			t.Errorf("python script missing %q:\n%s", want, py)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	decim "github.com/soypat/go-decim"
+)
+
+// sink writes the decimated points of a y column.
+type sink interface {
+	write(x, y float64) error
+	Close() error
+}
+
+// createSink creates the output file at path in the format given by its
+// extension. tol is the y tolerance of points written to it.
+func createSink(path, xname, yname string, tol float64, xc xCodec) (sink, error) {
+	ext := dataExtension(path)
+	format := formatFromExtension(ext)
+	fo, err := createCompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	if pgfplots {
+		return newDatSink(fo, xname, yname, xc), nil
+	}
+	switch format {
+	case formatNPY:
+		return &arraySink{WriteCloser: fo, flush: func(w io.Writer, x, y []float64) error {
+			data := make([]float64, 0, 2*len(x))
+			for i := range x {
+				data = append(data, x[i], y[i])
+			}
+			return decim.WriteNPY(w, &decim.NPY{Shape: []int{len(x), 2}, Data: data})
+		}}, nil
+	case formatNPZ:
+		names := []string{npzName(xname), npzName(yname)}
+		return &arraySink{WriteCloser: fo, flush: func(w io.Writer, x, y []float64) error {
+			return decim.WriteNPZ(w, names, []*decim.NPY{
+				{Shape: []int{len(x)}, Data: x},
+				{Shape: []int{len(y)}, Data: y},
+			})
+		}}, nil
+	case formatMAT:
+		xvar, yvar := matName(xname), matName(yname)
+		if xvar == yvar {
+			yvar += "_y"
+		}
+		return &arraySink{WriteCloser: fo, flush: func(w io.Writer, x, y []float64) error {
+			return decim.WriteMAT(w, []*decim.MatVar{
+				{Name: xvar, Dims: []int{len(x), 1}, Data: x},
+				{Name: yvar, Dims: []int{len(y), 1}, Data: y},
+			})
+		}}, nil
+	case formatSVG:
+		return &arraySink{WriteCloser: fo, flush: func(w io.Writer, x, y []float64) error {
+			width, height := svgSize()
+			xy := &xySlices{x: x, y: y}
+			svg := &decim.SVG{Viewport: decim.NewViewport(xy, width, height), Tolerance: tol, Axes: svgAxes}
+			return svg.Write(w, xy)
+		}}, nil
+	case formatBinary:
+		f := binaryFormat(ext)
+		return &arraySink{WriteCloser: fo, flush: func(w io.Writer, x, y []float64) error {
+			return decim.WriteBinary(w, f, x, y)
+		}}, nil
+	}
+	s := &csvSink{Closer: fo, Writer: csv.NewWriter(fo), xc: xc}
+	if !enforceComma {
+		s.Writer.Comma = rune(inputSeparator[0])
+	}
+	if !noHeader {
+		if err := s.Write([]string{xname, yname}); err != nil {
+			fo.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// npzName returns a valid archive member name for a column name.
+func npzName(name string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(name)
+}
+
+// matName returns a valid MATLAB variable name for a column name
+// by replacing invalid characters with underscores.
+func matName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || b[0] == '_' || b[0] >= '0' && b[0] <= '9' {
+		b = append([]byte("v"), b...)
+	}
+	if len(b) > 63 {
+		b = b[:63]
+	}
+	return string(b)
+}
+
+type csvSink struct {
+	io.Closer
+	*csv.Writer
+	xc xCodec
+}
+
+func (s *csvSink) write(x, y float64) error {
+	return s.Write([]string{s.xc.format(x), fmt.Sprintf(floatFormat, y)})
+}
+
+func (s *csvSink) Close() error {
+	s.Flush()
+	if err := s.Error(); err != nil {
+		s.Closer.Close()
+		return err
+	}
+	return s.Closer.Close()
+}
+
+// arraySink accumulates points and writes them all on Close, for
+// formats which need the number of points before the data.
+type arraySink struct {
+	io.WriteCloser
+	x, y  []float64
+	flush func(w io.Writer, x, y []float64) error
+}
+
+func (s *arraySink) write(x, y float64) error {
+	s.x, s.y = append(s.x, x), append(s.y, y)
+	return nil
+}
+
+// xySlices implements decim.XYer.
+type xySlices struct {
+	x, y []float64
+}
+
+func (s *xySlices) XY(i int) (x, y float64) { return s.x[i], s.y[i] }
+func (s *xySlices) Len() int                { return len(s.x) }
+
+func (s *arraySink) Close() error {
+	if err := s.flush(s.WriteCloser, s.x, s.y); err != nil {
+		s.WriteCloser.Close()
+		return err
+	}
+	return s.WriteCloser.Close()
+}
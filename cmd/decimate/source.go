@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	decim "github.com/soypat/go-decim"
+)
+
+// binary format flags
+var binDtype, binEndian, binLayout string
+var binColumns int
+
+// spiceStep is the stepped run of SPICE .raw files to read, starting at 1.
+var spiceStep int
+
+// Data formats. Formats are chosen by file extension.
+const (
+	formatCSV    = "csv"
+	formatNPY    = "npy"
+	formatNPZ    = "npz"
+	formatBinary = "bin"
+	formatSpice  = "raw"
+	formatMAT    = "mat"
+	formatWAV    = "wav"
+	formatSVG    = "svg"
+)
+
+// formatFromExtension returns the data format of files with extension ext.
+// Files of unknown extension are considered delimited text.
+func formatFromExtension(ext string) string {
+	switch strings.ToLower(ext) {
+	case "npy":
+		return formatNPY
+	case "npz":
+		return formatNPZ
+	case "bin", "f32", "f64":
+		return formatBinary
+	case "raw":
+		return formatSpice
+	case "mat":
+		return formatMAT
+	case "wav":
+		return formatWAV
+	case "svg":
+		return formatSVG
+	}
+	return formatCSV
+}
+
+// binaryFormat returns the raw binary format given by flags. Extensions
+// f32 and f64 override the --dtype flag.
+func binaryFormat(ext string) decim.BinaryFormat {
+	f := decim.BinaryFormat{
+		Order:       binary.LittleEndian,
+		Float32:     binDtype == "float32",
+		Columns:     binColumns,
+		ColumnMajor: binLayout == "columns",
+	}
+	if binEndian == "big" {
+		f.Order = binary.BigEndian
+	}
+	switch strings.ToLower(ext) {
+	case "f32":
+		f.Float32 = true
+	case "f64":
+		f.Float32 = false
+	}
+	return f
+}
+
+// source reads rows of a numerical table.
+type source interface {
+	// header returns the column names.
+	header() []string
+	// read reads the next row's values of columns cols into dst.
+	// The last of cols is the x column, which text sources parse
+	// with the x codec. read returns io.EOF when no rows remain.
+	read(cols []int, dst []float64) error
+	Close() error
+}
+
+// inputFormats are the values of --input-format.
+var inputFormats = []string{"csv", "npy", "npz", "bin", "f32", "f64", "raw", "mat", "wav"}
+
+// inputFormat overrides the extension which sets the input's data format.
+var inputFormat string
+
+// sniffExtension returns the extension of data files starting with head.
+// Unknown data is considered delimited text.
+func sniffExtension(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\x93NUMPY")):
+		return "npy"
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return "npz"
+	case bytes.HasPrefix(head, []byte("MATLAB 5.0")):
+		return "mat"
+	case bytes.HasPrefix(head, []byte("RIFF")) && len(head) >= 12 && string(head[8:12]) == "WAVE":
+		return "wav"
+	case bytes.HasPrefix(head, []byte("Title:")) || bytes.HasPrefix(head, []byte("T\x00i\x00t\x00l\x00e\x00:\x00")):
+		return "raw"
+	}
+	return "csv"
+}
+
+// openSource opens the data file at path, or stdin if path is "-", according
+// to --input-format, its extension or its first bytes, in that order.
+func openSource(path string, xc xCodec) (source, error) {
+	fi, err := openDecompressed(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %s", err)
+	}
+	ext := inputFormat
+	if ext == "" {
+		ext = dataExtension(path)
+	}
+	if ext == "" {
+		br := bufio.NewReaderSize(fi, 1<<16)
+		// Peek errors mean short input, which is sniffed as is.
+		head, _ := br.Peek(16)
+		ext = sniffExtension(head)
+		fi = &readCloser{Reader: br, closers: []io.Closer{fi}}
+	}
+	format := formatFromExtension(ext)
+	if _, ok := xc.(floatCodec); !ok && format != formatCSV {
+		fi.Close()
+		return nil, errors.New("--xformat only applies to delimited text input")
+	}
+	var src source
+	switch format {
+	case formatNPY:
+		src, err = newNPYSource(fi)
+	case formatNPZ:
+		src, err = newNPZSource(fi)
+	case formatBinary:
+		src, err = newBinarySource(fi, binaryFormat(ext))
+	case formatSpice:
+		src, err = newSpiceSource(fi)
+	case formatMAT:
+		src, err = newMATSource(fi)
+	case formatWAV:
+		src, err = newWAVSource(fi)
+	case formatSVG:
+		err = errors.New("SVG is an output format")
+	default:
+		src, err = newCSVSource(fi, xc)
+	}
+	if err != nil {
+		fi.Close()
+		return nil, fmt.Errorf("reading %s: %s", path, err)
+	}
+	return src, nil
+}
+
+type csvSource struct {
+	io.Closer
+	rdr     *csv.Reader
+	headers []string
+	xc      xCodec
+}
+
+func newCSVSource(rc io.ReadCloser, xc xCodec) (*csvSource, error) {
+	rdr := csv.NewReader(rc)
+	rdr.Comma = rune(inputSeparator[0])
+	rdr.TrimLeadingSpace = true
+	headers, err := rdr.Read()
+	if err != nil {
+		return nil, err
+	}
+	if findNumerical(headers) >= 0 {
+		return nil, fmt.Errorf("numerical header entry found: %s", headers[findNumerical(headers)])
+	}
+	rdr.ReuseRecord = true
+	return &csvSource{Closer: rc, rdr: rdr, headers: headers, xc: xc}, nil
+}
+
+func (s *csvSource) header() []string { return s.headers }
+
+func (s *csvSource) read(cols []int, dst []float64) error {
+	record, err := s.rdr.Read()
+	if err != nil {
+		return err
+	}
+	for i, c := range cols {
+		if i == len(cols)-1 {
+			dst[i], err = s.xc.parse(record[c])
+		} else {
+			dst[i], err = strconv.ParseFloat(record[c], 64)
+		}
+		if err != nil {
+			line, _ := s.rdr.FieldPos(c)
+			return fmt.Errorf("line %d: %s", line, err)
+		}
+	}
+	return nil
+}
+
+// numberedColumns returns column names "1" through "n" so
+// that names and numbers refer to the same columns.
+func numberedColumns(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = strconv.Itoa(i + 1)
+	}
+	return names
+}
+
+// columnsSource reads tables that have been loaded into memory.
+// Columns may differ in length, such as scalars stored next to
+// signals, as long as those read have the same length.
+type columnsSource struct {
+	names []string
+	cols  [][]float64
+	row   int
+}
+
+func newColumnsSource(names []string, cols [][]float64) *columnsSource {
+	return &columnsSource{names: names, cols: cols}
+}
+
+func (s *columnsSource) header() []string { return s.names }
+
+func (s *columnsSource) read(cols []int, dst []float64) error {
+	if len(cols) == 0 {
+		return io.EOF
+	}
+	n := len(s.cols[cols[0]])
+	if s.row == 0 {
+		for _, c := range cols[1:] {
+			if len(s.cols[c]) != n {
+				return fmt.Errorf("column %s length %d differs from column %s length %d", s.names[c], len(s.cols[c]), s.names[cols[0]], n)
+			}
+		}
+	}
+	if s.row >= n {
+		return io.EOF
+	}
+	for i, c := range cols {
+		dst[i] = s.cols[c][s.row]
+	}
+	s.row++
+	return nil
+}
+
+func (s *columnsSource) Close() error { return nil }
+
+// rowSource reads tables row by row from a stream of values.
+type rowSource struct {
+	io.Closer
+	names []string
+	buf   []float64
+	next  func([]float64) error
+}
+
+func (s *rowSource) header() []string { return s.names }
+
+func (s *rowSource) read(cols []int, dst []float64) error {
+	if err := s.next(s.buf); err != nil {
+		return err
+	}
+	for i, c := range cols {
+		dst[i] = s.buf[c]
+	}
+	return nil
+}
+
+func newNPYSource(rc io.ReadCloser) (source, error) {
+	n, err := decim.NewNPYReader(rc)
+	if err != nil {
+		return nil, err
+	}
+	shape := n.Shape()
+	if len(shape) != 2 {
+		return nil, fmt.Errorf("need a 2-D array of columns, got shape %v", shape)
+	}
+	if !n.FortranOrder() {
+		return &rowSource{Closer: rc, names: numberedColumns(shape[1]), buf: make([]float64, shape[1]), next: n.Read}, nil
+	}
+	// Column major data is loaded entirely.
+	a := &decim.NPY{Shape: shape, FortranOrder: true, Data: make([]float64, shape[0]*shape[1])}
+	if err := n.Read(a.Data); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	rc.Close()
+	cols := make([][]float64, shape[1])
+	for j := range cols {
+		cols[j] = a.Column(j)
+	}
+	return newColumnsSource(numberedColumns(shape[1]), cols), nil
+}
+
+// newNPZSource loads all arrays of a .npz archive. 1-D arrays are
+// columns named after the array. Columns of 2-D arrays are
+// named after the array and column number, i.e. "data:2".
+// Arrays of other shapes, such as scalars, are skipped.
+func newNPZSource(rc io.ReadCloser) (source, error) {
+	defer rc.Close()
+	// Archives need random access so compressed ones are decompressed to memory.
+	f, ok := rc.(*os.File)
+	var ra io.ReaderAt = f
+	var size int64
+	if ok {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		size = info.Size()
+	} else {
+		b, err := ioutil.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		ra, size = bytes.NewReader(b), int64(len(b))
+	}
+	names, arrays, err := decim.ReadNPZ(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	var colNames []string
+	var cols [][]float64
+	for i, a := range arrays {
+		switch len(a.Shape) {
+		case 1:
+			colNames = append(colNames, names[i])
+			cols = append(cols, a.Data)
+		case 2:
+			for j := 0; j < a.Shape[1]; j++ {
+				colNames = append(colNames, names[i]+":"+strconv.Itoa(j+1))
+				cols = append(cols, a.Column(j))
+			}
+		}
+	}
+	return newColumnsSource(colNames, cols), nil
+}
+
+// newMATSource loads all numeric variables of a MAT-file. Vectors, which
+// include scalars, are columns named after the variable. Columns of matrices
+// are named like .npz ones. Variables of more dimensions are skipped.
+func newMATSource(rc io.ReadCloser) (source, error) {
+	defer rc.Close()
+	vars, err := decim.ReadMAT(rc)
+	if err != nil {
+		return nil, err
+	}
+	var colNames []string
+	var cols [][]float64
+	for _, v := range vars {
+		switch {
+		case len(v.Dims) == 2 && (v.Dims[0] == 1 || v.Dims[1] == 1):
+			colNames = append(colNames, v.Name)
+			cols = append(cols, v.Data)
+		case len(v.Dims) == 2:
+			for j := 0; j < v.Dims[1]; j++ {
+				colNames = append(colNames, v.Name+":"+strconv.Itoa(j+1))
+				cols = append(cols, v.Column(j))
+			}
+		}
+	}
+	return newColumnsSource(colNames, cols), nil
+}
+
+// newWAVSource reads WAV audio as a "time" column in seconds
+// followed by a column per channel named "ch1", "ch2" and so on.
+func newWAVSource(rc io.ReadCloser) (source, error) {
+	wr, err := decim.NewWAVReader(rc)
+	if err != nil {
+		return nil, err
+	}
+	f := wr.Format()
+	names := []string{"time"}
+	for c := 1; c <= f.NumChannels; c++ {
+		names = append(names, "ch"+strconv.Itoa(c))
+	}
+	var frame int
+	next := func(dst []float64) error {
+		if err := wr.Read(dst[1:]); err != nil {
+			return err
+		}
+		dst[0] = float64(frame) / float64(f.SampleRate)
+		frame++
+		return nil
+	}
+	return &rowSource{Closer: rc, names: names, buf: make([]float64, len(names)), next: next}, nil
+}
+
+func newBinarySource(rc io.ReadCloser, f decim.BinaryFormat) (source, error) {
+	if f.Columns < 1 {
+		return nil, errors.New("raw binary input requires --ncols")
+	}
+	if f.ColumnMajor {
+		defer rc.Close()
+		cols, err := decim.ReadBinary(rc, f)
+		if err != nil {
+			return nil, err
+		}
+		return newColumnsSource(numberedColumns(f.Columns), cols), nil
+	}
+	br, err := decim.NewBinaryReader(rc, f)
+	if err != nil {
+		return nil, err
+	}
+	return &rowSource{Closer: rc, names: numberedColumns(f.Columns), buf: make([]float64, f.Columns), next: br.Read}, nil
+}
+
+// newSpiceSource loads the traces of --step of a SPICE .raw file. Columns are
+// named after variables. Complex variables hold their magnitude and are
+// accompanied by "re(name)", "im(name)" and "ph(name)" columns, phase in degrees.
+func newSpiceSource(rc io.ReadCloser) (source, error) {
+	defer rc.Close()
+	s, err := decim.ReadSpiceRaw(rc)
+	if err != nil {
+		return nil, err
+	}
+	step := spiceStep - 1
+	if step >= s.Steps() {
+		return nil, fmt.Errorf("--step %d out of range. file has %d steps", spiceStep, s.Steps())
+	}
+	var names []string
+	var cols [][]float64
+	for v, sv := range s.Vars {
+		re, im := s.Real(v, step), s.Imag(v, step)
+		if im == nil || v == 0 {
+			names = append(names, sv.Name)
+			cols = append(cols, re)
+			continue
+		}
+		mag, ph := make([]float64, len(re)), make([]float64, len(re))
+		for i := range re {
+			mag[i] = math.Hypot(re[i], im[i])
+			ph[i] = math.Atan2(im[i], re[i]) * 180 / math.Pi
+		}
+		names = append(names, sv.Name, "re("+sv.Name+")", "im("+sv.Name+")", "ph("+sv.Name+")")
+		cols = append(cols, mag, re, im, ph)
+	}
+	return newColumnsSource(names, cols), nil
+}
+
+// readColumns reads the x and y columns given by flags of the input at path
+// into memory. The x column is last in cols.
+func readColumns(path string, xc xCodec) (xname string, ynames []string, cols [][]float64, err error) {
+	src, err := openSource(path, xc)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer src.Close()
+	headers := src.header()
+	if xname, ynames, err = parseHeader(headers); err != nil {
+		return "", nil, nil, err
+	}
+	var yxIdx []int
+	for _, v := range append(ynames, xname) {
+		i := findStringInSlice(v, headers)
+		if i < 0 {
+			return "", nil, nil, fmt.Errorf("%s is not in columns:\n%v", v, headers)
+		}
+		yxIdx = append(yxIdx, i)
+	}
+	cols = make([][]float64, len(yxIdx))
+	row := make([]float64, len(yxIdx))
+	for {
+		if err := src.read(yxIdx, row); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", nil, nil, err
+		}
+		for i, v := range row {
+			cols[i] = append(cols[i], v)
+		}
+	}
+	return xname, ynames, cols, nil
+}
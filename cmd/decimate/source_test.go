@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	decim "github.com/soypat/go-decim"
+)
+
+var (
+	testT = []float64{0, 1, 2, 3, 4}
+	testV = []float64{0, 1, 0, -1, 0}
+)
+
+func TestMATSourceScalar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.mat")
+	fp, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = decim.WriteMAT(fp, []*decim.MatVar{
+		{Name: "t", Dims: []int{5, 1}, Data: testT},
+		{Name: "fs", Dims: []int{1, 1}, Data: []float64{1}},
+		{Name: "v", Dims: []int{1, 5}, Data: testV},
+	})
+	fp.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testScalarSource(t, path)
+}
+
+func TestNPZSourceScalar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.npz")
+	fp, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A 0-d array, as numpy saves Python scalars, is skipped.
+	err = decim.WriteNPZ(fp, []string{"t", "fs", "v", "gain"}, []*decim.NPY{
+		{Shape: []int{5}, Data: testT},
+		{Shape: []int{1}, Data: []float64{1}},
+		{Shape: []int{5}, Data: testV},
+		{Shape: []int{}, Data: []float64{2}},
+	})
+	fp.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testScalarSource(t, path)
+	src, err := openSource(path, newXCodec("float"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	if h := src.header(); findStringInSlice("gain", h) >= 0 {
+		t.Errorf("0-d array read as a column in header %v", h)
+	}
+}
+
+// testScalarSource checks the t and v columns of the file at path can be
+// read next to a scalar fs, which can't be read along with them.
+func testScalarSource(t *testing.T, path string) {
+	t.Helper()
+	src, err := openSource(path, newXCodec("float"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	h := src.header()
+	ti, vi, fsi := findStringInSlice("t", h), findStringInSlice("v", h), findStringInSlice("fs", h)
+	if ti < 0 || vi < 0 || fsi < 0 {
+		t.Fatalf("missing columns in header %v", h)
+	}
+	row := make([]float64, 2)
+	for i := range testT {
+		if err := src.read([]int{vi, ti}, row); err != nil {
+			t.Fatal(err)
+		}
+		if row[0] != testV[i] || row[1] != testT[i] {
+			t.Fatalf("row %d: got %v", i, row)
+		}
+	}
+	if err := src.read([]int{vi, ti}, row); !errors.Is(err, io.EOF) {
+		t.Fatalf("want EOF, got %v", err)
+	}
+
+	src2, err := openSource(path, newXCodec("float"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src2.Close()
+	if err := src2.read([]int{vi, fsi}, row); err == nil {
+		t.Error("expected error reading columns of different length")
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// statsTolerances are the tolerances output sizes are predicted for.
+// Those of defaultTolerances are used if none are given.
+var statsTolerances []float64
+
+// noiseMultiples are the multiples of a column's noise level
+// output sizes are predicted for when no tolerances are given.
+var noiseMultiples = []float64{1, 3, 10, 30}
+
+// rangeFractions are the fractions of a column's y range used
+// instead of noiseMultiples when it has no noise.
+var rangeFractions = []float64{0.001, 0.003, 0.01, 0.03}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().StringVarP(&xFlag, "xcol", "x", "", "X column name. May be column number starting at 1. (required)")
+	_ = statsCmd.MarkFlagRequired("xcol")
+	statsCmd.Flags().StringVarP(&yFlag, "ycols", "y", "", "Y column names/numbers separated by delimiter. Numbering starts at 1. All columns if not set")
+	statsCmd.Flags().StringVarP(&inputSeparator, "delimiter", "d", ",", "Delimiter token. Examples: '-d \\t' or '-d=\";\"'")
+	statsCmd.Flags().StringVar(&xFormat, "xformat", "float", "X column format: 'float', 'int', 'time' or a Go time layout")
+	statsCmd.Flags().Float64SliceVarP(&statsTolerances, "tolerance", "t", nil, "Tolerances to predict output size for, separated by commas. Defaults to 1, 3, 10 and 30 times each column's noise level, or 0.1, 0.3, 1 and 3 percent of its y range if it has no noise")
+	statsCmd.Flags().BoolVarP(&interp, "interp", "i", false, "Predict output size of the interpolating algorithm")
+	statsCmd.Flags().StringVar(&inputFormat, "input-format", "", "Input data format, overriding the file extension: "+strings.Join(inputFormats, ", "))
+	statsCmd.Flags().StringVar(&binDtype, "dtype", "float64", "Raw binary (.bin) value type: float32 or float64")
+	statsCmd.Flags().StringVar(&binEndian, "endian", "little", "Raw binary byte order: little or big")
+	statsCmd.Flags().IntVar(&binColumns, "ncols", 0, "Number of columns of raw binary input")
+	statsCmd.Flags().StringVar(&binLayout, "layout", "interleaved", "Raw binary layout: interleaved rows or columns stored one after the other")
+	statsCmd.Flags().IntVar(&spiceStep, "step", 1, "Stepped run of SPICE .raw input to read, starting at 1")
+}
+
+// statsCmd prints statistics of input columns to help choose a tolerance.
+var statsCmd = &cobra.Command{
+	Use:   "stats [filenames...]",
+	Short: "Print column statistics and predicted output sizes",
+	Long: `stats reads the columns decimate would and prints, per file,
+the number of rows and the monotonicity and spacing of the
+x column. For each y column it prints the number of NaN
+values, the y range, an estimate of the noise level and the
+number of points and CSV bytes decimate would write for a
+few tolerances.
+
+The noise level is estimated from the median absolute
+second difference of y, which assumes evenly spaced x values.
+Columns without noise, such as quantized or synthetic data,
+have output sizes predicted for fractions of their y range.
+
+	Example:
+
+decimate stats -x time -y "*" -t 0.1,0.01,0.001 data.csv
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("requires at least one argument as input filename")
+		}
+		if yFlag == "" {
+			yFlag = "*"
+		}
+		if strings.TrimSuffix(inputSeparator, "s") == "tab" || inputSeparator == "\\t" {
+			yFlag = strings.ReplaceAll(yFlag, "\\t", "\t")
+			inputSeparator = "\t"
+		}
+		if len(inputSeparator) != 1 {
+			return errors.New("delimiter should be one character. '\\t' and 'tab' work as an option")
+		}
+		for _, tol := range statsTolerances {
+			if !(tol >= 0) {
+				return fmt.Errorf("tolerance %g must not be negative", tol)
+			}
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		paths, err := expandInputs(args)
+		if err == nil {
+			for _, path := range paths {
+				if err = printStats(os.Stdout, path); err != nil {
+					err = fmt.Errorf("%s: %s", path, err)
+					break
+				}
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(logOut, "[ERR] %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// printStats reads the selected columns of the input at path and writes their statistics to w.
+func printStats(w io.Writer, path string) error {
+	xc := newXCodec(xFormat)
+	xname, ynames, cols, err := readColumns(path, xc)
+	if err != nil {
+		return err
+	}
+	xs := cols[len(cols)-1]
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\n", path)
+	fmt.Fprintf(tw, "  rows\t%d\n", len(xs))
+	fmt.Fprintf(tw, "  x column\t%s, %s\n", xname, monotonicity(xs))
+	if dx := spacings(xs); len(dx) > 0 {
+		fmt.Fprintf(tw, "  x spacing\tmin %s, median %s, max %s\n", xSpacing(dx[0]), xSpacing(median(dx)), xSpacing(dx[len(dx)-1]))
+	}
+	for i, yname := range ynames {
+		ys := cols[i]
+		ymin, ymax, nan := math.Inf(1), math.Inf(-1), 0
+		for _, y := range ys {
+			if math.IsNaN(y) {
+				nan++
+				continue
+			}
+			ymin, ymax = math.Min(ymin, y), math.Max(ymax, y)
+		}
+		noise := noiseLevel(ys)
+		fmt.Fprintf(tw, "\n%s\n", yname)
+		fmt.Fprintf(tw, "  NaN values\t%d\n", nan)
+		if nan < len(ys) {
+			fmt.Fprintf(tw, "  y range\t%g to %g\n", ymin, ymax)
+		}
+		fmt.Fprintf(tw, "  noise\t%g\n", noise)
+		tols := statsTolerances
+		if len(tols) == 0 {
+			tols = defaultTolerances(noise, ymax-ymin)
+		}
+		fmt.Fprintf(tw, "  tolerance\tpoints\tCSV bytes\n")
+		for _, tol := range tols {
+			points, size, err := predictSize(xs, ys, tol, xc)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(tw, "  %.4g\t%d (%.1f%%)\t%d\n", tol, points, 100*float64(points)/math.Max(1, float64(len(ys))), size)
+		}
+	}
+	fmt.Fprintln(tw)
+	return tw.Flush()
+}
+
+// defaultTolerances returns the tolerances output sizes are predicted for
+// when none are given, multiples of the noise level or fractions of the y
+// range without noise. A column with a single value has only tolerance 0.
+func defaultTolerances(noise, yrange float64) []float64 {
+	base, factors := noise, noiseMultiples
+	if noise == 0 {
+		base, factors = yrange, rangeFractions
+	}
+	if !(base > 0) {
+		return []float64{0}
+	}
+	tols := make([]float64, len(factors))
+	for i, f := range factors {
+		tols[i] = f * base
+	}
+	return tols
+}
+
+// monotonicity describes the order of the x values, ignoring NaNs.
+func monotonicity(xs []float64) string {
+	increasing, nondecreasing := true, true
+	prev := math.NaN()
+	for _, x := range xs {
+		if math.IsNaN(x) {
+			continue
+		}
+		if x <= prev {
+			increasing = false
+		}
+		if x < prev {
+			nondecreasing = false
+			break
+		}
+		prev = x
+	}
+	switch {
+	case increasing:
+		return "strictly increasing"
+	case nondecreasing:
+		return "non-decreasing (repeated values)"
+	}
+	return "not monotonic"
+}
+
+// spacings returns the sorted differences between consecutive x values.
+func spacings(xs []float64) []float64 {
+	var dx []float64
+	for i := 1; i < len(xs); i++ {
+		if d := xs[i] - xs[i-1]; !math.IsNaN(d) {
+			dx = append(dx, d)
+		}
+	}
+	sort.Float64s(dx)
+	return dx
+}
+
+// xSpacing formats a difference of x values, which are
+// nanoseconds for timestamps.
+func xSpacing(dx float64) string {
+	switch xFormat {
+	case "float", "int":
+		return fmt.Sprintf("%g", dx)
+	}
+	return time.Duration(dx).String()
+}
+
+// median returns the median of sorted values.
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// noiseLevel estimates the standard deviation of white noise in ys from the
+// median absolute second difference, which is little affected by the signal
+// when it is smooth relative to the sampling rate.
+func noiseLevel(ys []float64) float64 {
+	var d2 []float64
+	for i := 2; i < len(ys); i++ {
+		if d := math.Abs(ys[i] - 2*ys[i-1] + ys[i-2]); !math.IsNaN(d) {
+			d2 = append(d2, d)
+		}
+	}
+	if len(d2) == 0 {
+		return 0
+	}
+	sort.Float64s(d2)
+	// The second difference of white noise has standard deviation σ√6
+	// and the median absolute value of a normal variable is 0.6745σ.
+	return median(d2) / (0.6745 * math.Sqrt(6))
+}
+
+// predictSize decimates a column with tolerance tol as decimate
+// would and returns the number of points and CSV bytes written.
+func predictSize(xs, ys []float64, tol float64, xc xCodec) (points, size int, err error) {
+	cs := &countSink{xc: xc}
+	if err := decimateColumn(xs, ys, tol, cs); err != nil {
+		return 0, 0, err
+	}
+	return cs.points, cs.size, nil
+}
+
+// decimateColumn writes the points decimate would write for
+// a column in memory with tolerance tol to s.
+func decimateColumn(xs, ys []float64, tol float64, s sink) error {
+	j := job{sink: s, stepper: newStepper(tol)}
+	for i := range xs {
+		if err := j.process(xs[i], ys[i]); err != nil {
+			return err
+		}
+	}
+	return j.process(math.NaN(), math.NaN())
+}
+
+// countSink counts the points and bytes of a CSV output without writing it.
+type countSink struct {
+	xc           xCodec
+	points, size int
+}
+
+func (s *countSink) write(x, y float64) error {
+	s.points++
+	s.size += len(s.xc.format(x)) + len(fmt.Sprintf(floatFormat, y)) + 2
+	return nil
+}
+
+func (s *countSink) Close() error { return nil }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNoiseLevel(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const sigma = 0.01
+	ys := make([]float64, 10000)
+	for i := range ys {
+		ys[i] = math.Sin(float64(i)/1000) + sigma*rng.NormFloat64()
+	}
+	if got := noiseLevel(ys); math.Abs(got-sigma) > 0.1*sigma {
+		t.Errorf("got noise level %g, want about %g", got, sigma)
+	}
+	ys[5000] = math.NaN()
+	if got := noiseLevel(ys); math.IsNaN(got) {
+		t.Error("got NaN noise level with a NaN value")
+	}
+	if got := noiseLevel([]float64{0, 1, 2, 3, 4}); got != 0 {
+		t.Errorf("got noise level %g for a line, want 0", got)
+	}
+}
+
+func TestMonotonicity(t *testing.T) {
+	nan := math.NaN()
+	for _, test := range []struct {
+		xs   []float64
+		want string
+	}{
+		{xs: []float64{0, 1, 2}, want: "strictly increasing"},
+		{xs: []float64{0, nan, 1, 2}, want: "strictly increasing"},
+		{xs: []float64{0, 1, 1, 2}, want: "non-decreasing (repeated values)"},
+		{xs: []float64{0, 2, 1}, want: "not monotonic"},
+		{xs: []float64{0, 1, 1, 0}, want: "not monotonic"},
+	} {
+		if got := monotonicity(test.xs); got != test.want {
+			t.Errorf("monotonicity(%v) = %q, want %q", test.xs, got, test.want)
+		}
+	}
+}
+
+func TestSpacings(t *testing.T) {
+	got := spacings([]float64{0, 1, 3, math.NaN(), 4, 4.5})
+	want := []float64{0.5, 1, 2}
+	if len(got) != len(want) {
+		t.Fatalf("got spacings %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got spacings %v, want %v", got, want)
+		}
+	}
+	if m := median(got); m != 1 {
+		t.Errorf("got median %g, want 1", m)
+	}
+}
+
+func TestDefaultTolerances(t *testing.T) {
+	for _, test := range []struct {
+		noise, yrange float64
+		want          []float64
+	}{
+		{noise: 0.1, yrange: 2, want: []float64{0.1, 0.3, 1, 3}},
+		{noise: 0, yrange: 100, want: []float64{0.1, 0.3, 1, 3}},
+		{noise: 0, yrange: 0, want: []float64{0}},
+		{noise: 0, yrange: math.Inf(-1), want: []float64{0}}, // all NaN
+	} {
+		got := defaultTolerances(test.noise, test.yrange)
+		if len(got) != len(test.want) {
+			t.Errorf("defaultTolerances(%g, %g) = %v, want %v", test.noise, test.yrange, got, test.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-test.want[i]) > 1e-12 {
+				t.Errorf("defaultTolerances(%g, %g) = %v, want %v", test.noise, test.yrange, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestPredictSize(t *testing.T) {
+	silent = true
+	defer func(x, y string, tol []float64) { xFlag, yFlag, statsTolerances, silent = x, y, tol, false }(xFlag, yFlag, statsTolerances)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	var b strings.Builder
+	b.WriteString("t,a,b,c\n")
+	for i := 0; i < 3000; i++ {
+		x := float64(i) / 100
+		fmt.Fprintf(&b, "%g,%g,%g,%g\n", x, math.Sin(x), math.Cos(3*x), float64(i%7))
+	}
+	if err := ioutil.WriteFile(input, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	written := decimateWith(t, input, dir, runSequential)
+	xFlag, yFlag = "t", "*"
+	xc := newXCodec("float")
+	_, ynames, cols, err := readColumns(input, xc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, yname := range ynames {
+		points, size, err := predictSize(cols[len(cols)-1], cols[i], 0.01, xc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Written files start with a header line.
+		out := written[i]
+		header := bytes.IndexByte(out, '\n') + 1
+		if lines := bytes.Count(out, []byte("\n")) - 1; points != lines {
+			t.Errorf("%s: predicted %d points, decimate wrote %d", yname, points, lines)
+		}
+		if size != len(out)-header {
+			t.Errorf("%s: predicted %d bytes, decimate wrote %d", yname, size, len(out)-header)
+		}
+	}
+
+	// Column c has no noise and gets tolerances from its y range.
+	statsTolerances = nil
+	var report bytes.Buffer
+	if err := printStats(&report, input); err != nil {
+		t.Fatal(err)
+	}
+	c := report.String()[strings.Index(report.String(), "\nc\n"):]
+	seen := map[string]bool{}
+	rows := strings.Split(c[strings.Index(c, "tolerance"):], "\n")[1:]
+	for _, row := range rows {
+		if f := strings.Fields(row); len(f) > 0 {
+			seen[f[0]] = true
+		}
+	}
+	if len(seen) != len(rangeFractions) || seen["0"] {
+		t.Errorf("want %d distinct positive tolerances for column without noise:\n%s", len(rangeFractions), c)
+	}
+}
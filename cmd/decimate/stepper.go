@@ -1,17 +1,19 @@
 package main
 
 import (
-	"fmt"
 	"math"
 )
 
 type stepper interface {
 	step(x, y float64) stepper
-	values(format string) []string
+	// xy returns the point to be written when ready.
+	xy() (x, y float64)
 	ready() bool
 }
 
 type inPlaceStepper struct {
+	tol                          float64
+	maxDx                        float64 // if positive, max x distance between points written
 	xsaved, ysaved               float64 // saved values for printing
 	xstart, ystart, xprev, yprev float64
 	anglemin, anglemax           float64
@@ -19,8 +21,8 @@ type inPlaceStepper struct {
 	rdy                          bool
 }
 
-func (a inPlaceStepper) values(format string) []string {
-	return []string{fmt.Sprintf(format, a.xstart), fmt.Sprintf(format, a.ystart)}
+func (a inPlaceStepper) xy() (x, y float64) {
+	return a.xstart, a.ystart
 }
 
 func (a inPlaceStepper) ready() bool {
@@ -36,15 +38,15 @@ func (a inPlaceStepper) step(x, y float64) stepper {
 		return a
 	}
 	Dx, Dy := x-a.xstart, y-a.ystart
-	loangle, hiangle, angle := math.Atan2(Dy-tolerance, Dx), math.Atan2(Dy+tolerance, Dx), math.Atan2(Dy, Dx)
+	loangle, hiangle, angle := math.Atan2(Dy-a.tol, Dx), math.Atan2(Dy+a.tol, Dx), math.Atan2(Dy, Dx)
 	if a.stepNo == 1 {
 		a.anglemin, a.anglemax = loangle, hiangle
 	}
 	// condition set to trigger on NaN too
-	if !(angle >= a.anglemin) || !(angle <= a.anglemax) { // Finding a value steps our algorithm twice
+	if !(angle >= a.anglemin) || !(angle <= a.anglemax) || a.maxDx > 0 && Dx > a.maxDx { // Finding a value steps our algorithm twice
 		a.xstart, a.ystart, a.xprev, a.yprev = a.xprev, a.yprev, x, y
 		Dx, Dy = x-a.xstart, y-a.ystart
-		a.anglemin, a.anglemax = math.Atan2(Dy-tolerance, Dx), math.Atan2(Dy+tolerance, Dx)
+		a.anglemin, a.anglemax = math.Atan2(Dy-a.tol, Dx), math.Atan2(Dy+a.tol, Dx)
 		a.stepNo++
 		a.rdy = true
 		return a
@@ -60,8 +62,60 @@ func (a inPlaceStepper) step(x, y float64) stepper {
 	return a
 }
 
+// swingingDoorStepper keeps points with the swinging door algorithm
+// (Bristol). Doors hinged dev above and below the last point written
+// close on the points after it, and the point before the one which
+// opens them past parallel is written. Points in between lie within dev
+// of a line from the last point written and within 2*dev of the line to
+// the next.
+type swingingDoorStepper struct {
+	dev                          float64
+	window                       float64 // if positive, max x distance between points written
+	xstart, ystart, xprev, yprev float64
+	slopemin, slopemax           float64 // slopes of the lines from the start within dev of all points
+	stepNo                       int
+	rdy                          bool
+}
+
+func (a swingingDoorStepper) xy() (x, y float64) {
+	return a.xstart, a.ystart
+}
+
+func (a swingingDoorStepper) ready() bool {
+	return a.rdy
+}
+
+func (a swingingDoorStepper) step(x, y float64) stepper {
+	a.rdy = false
+	if a.stepNo == 0 {
+		a.xstart, a.xprev, a.ystart, a.yprev = x, x, y, y
+		a.stepNo++
+		a.rdy = true
+		return a
+	}
+	Dx, Dy := x-a.xstart, y-a.ystart
+	loslope, hislope := (Dy-a.dev)/Dx, (Dy+a.dev)/Dx
+	if a.stepNo == 1 {
+		a.slopemin, a.slopemax = loslope, hislope
+	}
+	// condition set to trigger on NaN too
+	if !(math.Max(a.slopemin, loslope) <= math.Min(a.slopemax, hislope)) || a.window > 0 && Dx > a.window {
+		a.xstart, a.ystart, a.xprev, a.yprev = a.xprev, a.yprev, x, y
+		Dx, Dy = x-a.xstart, y-a.ystart
+		a.slopemin, a.slopemax = (Dy-a.dev)/Dx, (Dy+a.dev)/Dx
+		a.stepNo++
+		a.rdy = true
+		return a
+	}
+	a.slopemin, a.slopemax = math.Max(a.slopemin, loslope), math.Min(a.slopemax, hislope)
+	a.stepNo++
+	a.xprev, a.yprev = x, y
+	return a
+}
+
 // Interpolator
 type interpStepper struct {
+	tol                          float64
 	xstart, ystart, xprev, yprev float64
 	anglemin, anglemax           float64
 	stepNo                       int
@@ -77,7 +131,7 @@ func (a interpStepper) step(x, y float64) stepper {
 		return a
 	}
 	Dx, Dy := x-a.xstart, y-a.ystart
-	loangle, hiangle := math.Atan2(Dy-tolerance, Dx), math.Atan2(Dy+tolerance, Dx)
+	loangle, hiangle := math.Atan2(Dy-a.tol, Dx), math.Atan2(Dy+a.tol, Dx)
 	if a.stepNo == 1 {
 		a.anglemin, a.anglemax = loangle, hiangle
 	}
@@ -86,7 +140,7 @@ func (a interpStepper) step(x, y float64) stepper {
 		a.ystart = a.ystart + (a.xprev-a.xstart)*(math.Tan(a.anglemax)+math.Tan(a.anglemin))/2 // interpolator
 		a.xstart, a.xprev, a.yprev = a.xprev, x, y
 		Dx, Dy = x-a.xstart, y-a.ystart
-		a.anglemin, a.anglemax = math.Atan2(Dy-tolerance, Dx), math.Atan2(Dy+tolerance, Dx)
+		a.anglemin, a.anglemax = math.Atan2(Dy-a.tol, Dx), math.Atan2(Dy+a.tol, Dx)
 		a.stepNo++
 		a.rdy = true
 		return a
@@ -102,8 +156,8 @@ func (a interpStepper) step(x, y float64) stepper {
 	return a
 }
 
-func (a interpStepper) values(format string) []string {
-	return []string{fmt.Sprintf(format, a.xstart), fmt.Sprintf(format, a.ystart)}
+func (a interpStepper) xy() (x, y float64) {
+	return a.xstart, a.ystart
 }
 
 func (a interpStepper) ready() bool {
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	decim "github.com/soypat/go-decim"
+	"github.com/spf13/cobra"
+)
+
+// worstOffenders is the number of samples listed when verification fails.
+var worstOffenders int
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().StringVarP(&xFlag, "xcol", "x", "", "X column name of the original file. May be column number starting at 1. (required)")
+	_ = verifyCmd.MarkFlagRequired("xcol")
+	verifyCmd.Flags().Float64VarP(&tolerance, "tolerance", "t", 0.1, "Tolerance the decimated files were written with")
+	verifyCmd.Flags().StringVar(&heightFlag, "height", "", "Plot height the decimated files were written for, in pixels or physical units with --dpi. Overrides --tolerance")
+	verifyCmd.Flags().Float64Var(&dpi, "dpi", 0, "Dots per inch for --height given in physical units")
+	verifyCmd.Flags().StringVarP(&inputSeparator, "delimiter", "d", ",", "Delimiter token of original and decimated files")
+	verifyCmd.Flags().StringVarP(&floatFormat, "fformat", "f", "%.6e", "Floating point format the decimated files were written with. Their rounding is allowed on top of the tolerance")
+	verifyCmd.Flags().StringVar(&xFormat, "xformat", "float", "X column format: 'float', 'int', 'time' or a Go time layout")
+	verifyCmd.Flags().IntVar(&worstOffenders, "worst", 10, "Number of worst samples listed on failure")
+	verifyCmd.Flags().StringVar(&binDtype, "dtype", "float64", "Raw binary (.bin) value type: float32 or float64")
+	verifyCmd.Flags().StringVar(&binEndian, "endian", "little", "Raw binary byte order: little or big")
+	verifyCmd.Flags().IntVar(&binColumns, "ncols", 0, "Number of columns of raw binary original")
+	verifyCmd.Flags().StringVar(&binLayout, "layout", "interleaved", "Raw binary layout: interleaved rows or columns stored one after the other")
+	verifyCmd.Flags().IntVar(&spiceStep, "step", 1, "Stepped run of SPICE .raw original to read, starting at 1")
+}
+
+// verifyCmd checks decimated files against the original they were written from.
+var verifyCmd = &cobra.Command{
+	Use:   "verify original decimated...",
+	Short: "Check decimated files are within tolerance of the original",
+	Long: `verify checks that every sample of the original file lies within
+the tolerance of the decimated data, which is linearly interpolated
+between its points. Decimated files are those written by decimate:
+an x column and a y column named after the original column. Files
+without a header are matched to a column by their name, as in
+data-<ycol>.csv. Samples outside the x range of the decimated data
+fail verification.
+
+verify exits with a non-zero status and lists the worst samples of
+each file that fails.
+
+	Example:
+
+decimate -x time -y "*" -t 0.01 data.csv
+decimate verify -x time -t 0.01 data.csv data-*.csv
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("requires the original file and at least one decimated file")
+		}
+		if strings.TrimSuffix(inputSeparator, "s") == "tab" || inputSeparator == "\\t" {
+			inputSeparator = "\t"
+		}
+		if len(inputSeparator) != 1 {
+			return errors.New("delimiter should be one character. '\\t' and 'tab' work as an option")
+		}
+		heightPx = 0
+		if heightFlag != "" {
+			var err error
+			if heightPx, err = parseLength(heightFlag, dpi); err != nil {
+				return err
+			}
+		}
+		const floatNum = .125
+		if _, err := strconv.ParseFloat(fmt.Sprintf(floatFormat, floatNum), 64); err != nil {
+			return errors.New("formatting option yielded error: " + err.Error())
+		}
+		yFlag = "*"
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		decimated, err := expandInputs(args[1:])
+		if err == nil {
+			var ok bool
+			ok, err = verify(os.Stdout, args[0], decimated)
+			if err == nil && !ok {
+				os.Exit(1)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(logOut, "[ERR] %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// offender is an original sample and its distance to the decimated data.
+type offender struct {
+	x, y, yi, err float64
+}
+
+// verify checks each decimated file against the column of original it holds
+// and writes a report to w. ok is false if any file fails verification.
+func verify(w io.Writer, original string, decimated []string) (ok bool, err error) {
+	// Sharing the x codec keeps integer and timestamp epochs consistent across files.
+	xc := newXCodec(xFormat)
+	xname, ynames, cols, err := readColumns(original, xc)
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", original, err)
+	}
+	xs := cols[len(cols)-1]
+
+	ok = true
+	for _, path := range decimated {
+		dx, dy, yname, err := readDecimated(path, ynames, xc)
+		if err != nil {
+			return false, fmt.Errorf("%s: %s", path, err)
+		}
+		ys := cols[findStringInSlice(yname, ynames)]
+		tol := tolerance
+		if heightPx > 0 {
+			vp := decim.Viewport{YMin: math.Inf(1), YMax: math.Inf(-1), Height: heightPx, Width: 1}
+			for _, y := range ys {
+				includeY(&vp, y)
+			}
+			if _, tol = vp.Tolerance(); math.IsInf(tol, 0) || math.IsNaN(tol) {
+				tol = 0
+			}
+		}
+		var bad []offender
+		maxErr := 0.0
+		for i, x := range xs {
+			if math.IsNaN(x) || math.IsNaN(ys[i]) {
+				continue
+			}
+			xq := x
+			if n := len(dx); xFormat == "float" && n > 0 {
+				// Rounding may leave the first and last samples just outside decimated data.
+				switch u := formatUnit(x) / 2; {
+				case x < dx[0] && dx[0]-x <= u:
+					xq = dx[0]
+				case x > dx[n-1] && x-dx[n-1] <= u:
+					xq = dx[n-1]
+				}
+			}
+			yi, slope := interpolate(dx, dy, xq)
+			e := math.Abs(ys[i] - yi)
+			if math.IsNaN(e) {
+				e = math.Inf(1) // outside decimated data.
+			}
+			maxErr = math.Max(maxErr, e)
+			// Decimated points are rounded when formatted.
+			rounding := formatUnit(yi) / 2
+			if xFormat == "float" {
+				rounding += math.Abs(slope) * formatUnit(x) / 2
+			}
+			if e > tol+rounding {
+				bad = append(bad, offender{x: x, y: ys[i], yi: yi, err: e})
+			}
+		}
+		if len(bad) == 0 {
+			fmt.Fprintf(w, "%s (%s): OK. %d points for %d samples, max error %g within tolerance %g\n", path, yname, len(dx), len(xs), maxErr, tol)
+			continue
+		}
+		ok = false
+		fmt.Fprintf(w, "%s (%s): FAIL. %d of %d samples exceed tolerance %g, max error %g\n", path, yname, len(bad), len(xs), tol, maxErr)
+		sort.SliceStable(bad, func(i, j int) bool { return bad[i].err > bad[j].err })
+		if len(bad) > worstOffenders {
+			bad = bad[:worstOffenders]
+		}
+		for _, o := range bad {
+			fmt.Fprintf(w, "\t%s=%s\t%s=%g\tdecimated=%g\terror=%g\n", xname, xc.format(o.x), yname, o.y, o.yi, o.err)
+		}
+	}
+	return ok, nil
+}
+
+// readDecimated reads the points of a decimated file and finds
+// the column of ynames it holds by its header or filename.
+func readDecimated(path string, ynames []string, xc xCodec) (xs, ys []float64, yname string, err error) {
+	src, err := openSource(path, xc)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer src.Close()
+	headers := src.header()
+	if len(headers) != 2 {
+		return nil, nil, "", fmt.Errorf("want x and y columns, got %d columns", len(headers))
+	}
+	yname = headers[1]
+	if findStringInSlice(yname, ynames) < 0 {
+		// Headerless and binary files are matched by their <name>-<ycol> filename.
+		name, _ := splitCompression(discardPath(path))
+		name, _ = splitFileExtension(name)
+		yname = ""
+		for _, c := range ynames {
+			if strings.HasSuffix(name, "-"+replaceCutset(c, badFilenameChar, "-")) && len(c) > len(yname) {
+				yname = c
+			}
+		}
+		if yname == "" {
+			return nil, nil, "", errors.New("no column of the original matches its header or filename")
+		}
+	}
+	row := make([]float64, 2)
+	for {
+		if err := src.read([]int{1, 0}, row); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, "", err
+		}
+		ys, xs = append(ys, row[0]), append(xs, row[1])
+	}
+	return xs, ys, yname, nil
+}
+
+// interpolate returns the y value and slope at x of the polyline through points
+// xs, ys sorted by x. It returns NaN if x is outside the range of xs. NaN points
+// break the polyline, so the other end of a segment ending in NaN is used.
+func interpolate(xs, ys []float64, x float64) (y, slope float64) {
+	i := sort.SearchFloat64s(xs, x)
+	switch {
+	case i == len(xs):
+		return math.NaN(), 0
+	case xs[i] == x:
+		return ys[i], 0
+	case i == 0:
+		return math.NaN(), 0
+	case math.IsNaN(ys[i]):
+		return ys[i-1], 0
+	case math.IsNaN(ys[i-1]):
+		return ys[i], 0
+	}
+	slope = (ys[i] - ys[i-1]) / (xs[i] - xs[i-1])
+	return ys[i-1] + (x-xs[i-1])*slope, slope
+}
+
+// formatUnit returns the unit in the last place of v formatted with
+// floatFormat, such as 1e-6 for 1.234567e+00 or 0.01 for 12.34.
+func formatUnit(v float64) float64 {
+	s := strings.ToLower(fmt.Sprintf(floatFormat, math.Abs(v)))
+	exp := 0
+	if i := strings.IndexByte(s, 'e'); i >= 0 {
+		exp, _ = strconv.Atoi(s[i+1:])
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		exp -= len(s) - i - 1
+	}
+	return math.Pow10(exp)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVerifyTampered(t *testing.T) {
+	silent = true
+	defer func(x, y string, tol float64) { xFlag, yFlag, tolerance, silent = x, y, tol, false }(xFlag, yFlag, tolerance)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	var b strings.Builder
+	b.WriteString("t,a,b,c\n")
+	for i := 0; i < 2000; i++ {
+		x := float64(i) / 100
+		fmt.Fprintf(&b, "%g,%g,%g,%g\n", x, math.Sin(x), math.Cos(3*x), float64(i%7))
+	}
+	if err := ioutil.WriteFile(input, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	decimateWith(t, input, dir, runSequential)
+	decimated := []string{filepath.Join(dir, "out-a.csv"), filepath.Join(dir, "out-b.csv"), filepath.Join(dir, "out-c.csv")}
+	xFlag, yFlag, tolerance = "t", "*", 0.01
+	var report bytes.Buffer
+	ok, err := verify(&report, input, decimated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatalf("decimated files failed verification:\n%s", report.String())
+	}
+
+	// Move a point of column b off by more than the tolerance.
+	data, err := ioutil.ReadFile(decimated[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(data), "\n")
+	i := len(lines) / 2
+	fields := strings.Split(lines[i], ",")
+	y, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines[i] = fields[0] + "," + fmt.Sprintf(floatFormat, y+0.1)
+	if err := ioutil.WriteFile(decimated[1], []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report.Reset()
+	ok, err = verify(&report, input, decimated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatalf("tampered file passed verification:\n%s", report.String())
+	}
+	out := report.String()
+	if !strings.Contains(out, "out-b.csv (b): FAIL") || strings.Contains(out, "(a): FAIL") || strings.Contains(out, "(c): FAIL") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+}
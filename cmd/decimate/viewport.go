@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	decim "github.com/soypat/go-decim"
+)
+
+// viewport flags
+var widthFlag, heightFlag string
+var dpi float64
+
+// plot area size in pixels obtained from flags. Zero if not set.
+// Only heightPx sets tolerances since decimate bounds vertical error.
+var widthPx, heightPx float64
+
+// svgAxes draws axes around SVG output.
+var svgAxes bool
+
+// svgSize returns the plot area size of SVG output. Missing
+// dimensions are derived from the other one with a 4:3 aspect ratio.
+func svgSize() (width, height float64) {
+	width, height = widthPx, heightPx
+	switch {
+	case width == 0 && height == 0:
+		return 640, 480
+	case width == 0:
+		width = height * 4 / 3
+	case height == 0:
+		height = width * 3 / 4
+	}
+	return width, height
+}
+
+// parseLength returns the number of pixels in a length such as "1080",
+// "1080px", "8cm", "80mm" or "3.5in". Physical units require dpi.
+func parseLength(s string, dpi float64) (float64, error) {
+	var perInch float64
+	num := s
+	for _, u := range []struct {
+		suffix string
+		perIn  float64
+	}{{"px", 0}, {"cm", 2.54}, {"mm", 25.4}, {"in", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			num, perInch = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.perIn
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || !(v > 0) {
+		return 0, fmt.Errorf("bad length %q. examples: 1080, 1080px, 8cm, 80mm, 3.5in", s)
+	}
+	if perInch == 0 {
+		return v, nil
+	}
+	if !(dpi > 0) {
+		return 0, fmt.Errorf("length %q in physical units requires a positive --dpi value", s)
+	}
+	vp := decim.Viewport{}
+	vp.SetPhysicalSize(v/perInch, 0, dpi)
+	return vp.Width, nil
+}
+
+// viewportTolerances reads the file at path and returns the tolerance
+// for each y column so that decimation error stays below half a pixel
+// when the column is drawn heightPx pixels tall. yxIdx holds the y column
+// indices followed by the x column index.
+func viewportTolerances(path string, yxIdx []int) ([]float64, error) {
+	src, err := openSource(path, newXCodec(xFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	nys := len(yxIdx) - 1
+	vps := make([]decim.Viewport, nys)
+	for i := range vps {
+		vps[i] = decim.Viewport{YMin: math.Inf(1), YMax: math.Inf(-1), Height: heightPx, Width: 1}
+	}
+	row := make([]float64, len(yxIdx))
+	for {
+		if err := src.read(yxIdx, row); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		for i := range vps {
+			includeY(&vps[i], row[i])
+		}
+	}
+	tols := make([]float64, nys)
+	for i, vp := range vps {
+		_, tols[i] = vp.Tolerance()
+		if math.IsInf(tols[i], 0) || math.IsNaN(tols[i]) {
+			tols[i] = 0 // no data.
+		}
+	}
+	return tols, nil
+}
+
+// includeY extends the y range of vp to include y. NaN values, which
+// are gaps in the data, are skipped as stats does.
+func includeY(vp *decim.Viewport, y float64) {
+	if !math.IsNaN(y) {
+		vp.YMin, vp.YMax = math.Min(vp.YMin, y), math.Max(vp.YMax, y)
+	}
+}
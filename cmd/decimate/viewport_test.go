@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHeightTolerancesSkipNaN(t *testing.T) {
+	silent = true
+	defer func(x, y string, tol, height float64) {
+		xFlag, yFlag, tolerance, heightPx, silent = x, y, tol, height, false
+	}(xFlag, yFlag, tolerance, heightPx)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	var b strings.Builder
+	b.WriteString("t,a,b,c\n")
+	amin, amax := math.Inf(1), math.Inf(-1)
+	for i := 0; i < 2000; i++ {
+		x := float64(i) / 100
+		a := math.Sin(x)
+		if i%100 == 50 {
+			a = math.NaN()
+		} else {
+			amin, amax = math.Min(amin, a), math.Max(amax, a)
+		}
+		fmt.Fprintf(&b, "%g,%g,%g,%g\n", x, a, math.Cos(3*x), float64(i%7))
+	}
+	if err := ioutil.WriteFile(input, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	heightPx = 50
+	tols, err := viewportTolerances(input, []int{1, 2, 3, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (amax - amin) / heightPx / 2; math.Abs(tols[0]-want) > 1e-9 {
+		t.Errorf("got tolerance %g for column with NaN values, want %g", tols[0], want)
+	}
+
+	// Files decimated within a quarter of a pixel pass verification for the same height.
+	decimateWith(t, input, dir, runSequential)
+	decimated := []string{filepath.Join(dir, "out-a.csv"), filepath.Join(dir, "out-b.csv"), filepath.Join(dir, "out-c.csv")}
+	xFlag, yFlag = "t", "*"
+	var report bytes.Buffer
+	ok, err := verify(&report, input, decimated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("decimated files failed verification with --height:\n%s", report.String())
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xCodec converts x column text to float64 values for the
+// downsampling algorithms and back to text in the original format.
+// Integer and time codecs subtract the first value read (the epoch)
+// so that nanosecond timestamps survive the float64 conversion.
+type xCodec interface {
+	parse(s string) (float64, error)
+	format(x float64) string
+}
+
+func newXCodec(xformat string) xCodec {
+	switch xformat {
+	case "", "float":
+		return floatCodec{}
+	case "int":
+		return &intCodec{}
+	case "time", "rfc3339", "iso8601":
+		return &timeCodec{}
+	default:
+		return &timeCodec{layout: xformat, fixedLayout: true}
+	}
+}
+
+type floatCodec struct{}
+
+func (floatCodec) parse(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+
+func (floatCodec) format(x float64) string { return fmt.Sprintf(floatFormat, x) }
+
+// intCodec handles integer x values such as Unix nanosecond timestamps.
+type intCodec struct {
+	epoch    int64
+	epochSet bool
+}
+
+func (c *intCodec) parse(s string) (float64, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if !c.epochSet {
+		c.epoch, c.epochSet = i, true
+	}
+	return float64(i - c.epoch), nil
+}
+
+func (c *intCodec) format(x float64) string {
+	return strconv.FormatInt(c.epoch+int64(math.Round(x)), 10)
+}
+
+// timeCodec handles timestamp strings. If layout is not fixed
+// it is detected from the first value as an ISO-8601 layout and
+// widened to the most fractional second digits read so far, so
+// values are written back without losing precision.
+type timeCodec struct {
+	layout      string
+	parseLayout string
+	fixedLayout bool
+	// frac is the number of fractional second digits of layout.
+	frac     int
+	loc      *time.Location
+	epoch    int64
+	epochSet bool
+}
+
+func (c *timeCodec) parse(s string) (float64, error) {
+	if !c.epochSet {
+		if !c.fixedLayout {
+			layout, err := detectTimeLayout(s)
+			if err != nil {
+				return 0, err
+			}
+			c.layout, c.frac = layout, fractionDigits(s)
+		}
+		t, err := time.Parse(c.layout, s)
+		if err != nil {
+			return 0, err
+		}
+		c.loc, c.epoch, c.epochSet = t.Location(), t.UnixNano(), true
+		c.parseLayout = c.layout
+		if i := strings.Index(c.layout, "05.0"); i >= 0 && !c.fixedLayout {
+			// Fractional seconds are parsed regardless of layout,
+			// so we accept inputs with varying number of digits.
+			c.parseLayout = c.layout[:i+2] + strings.TrimLeft(c.layout[i+3:], "0")
+		}
+		return 0, nil
+	}
+	t, err := time.Parse(c.parseLayout, s)
+	if err != nil {
+		return 0, err
+	}
+	if n := fractionDigits(s); n > c.frac && !c.fixedLayout {
+		i := strings.Index(c.layout, ":05") + 3
+		rest := c.layout[i:]
+		if c.frac > 0 {
+			rest = rest[1+c.frac:]
+		}
+		c.layout, c.frac = c.layout[:i]+"."+strings.Repeat("0", n)+rest, n
+	}
+	return float64(t.UnixNano() - c.epoch), nil
+}
+
+// fractionDigits returns the number of fractional second
+// digits of an ISO-8601 timestamp.
+func fractionDigits(s string) int {
+	const datetime = "2006-01-02T15:04:05"
+	if len(s) <= len(datetime) || s[len(datetime)] != '.' {
+		return 0
+	}
+	n := 0
+	for _, r := range s[len(datetime)+1:] {
+		if r < '0' || r > '9' {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+func (c *timeCodec) format(x float64) string {
+	return time.Unix(0, c.epoch+int64(math.Round(x))).In(c.loc).Format(c.layout)
+}
+
+// detectTimeLayout returns the ISO-8601 layout of s, preserving
+// the number of fractional second digits and the zone notation
+// so that output timestamps look like the input.
+func detectTimeLayout(s string) (string, error) {
+	const datetime = "2006-01-02T15:04:05"
+	if len(s) < len(datetime) || (s[10] != 'T' && s[10] != ' ') {
+		return "", fmt.Errorf("%q is not an ISO-8601 timestamp", s)
+	}
+	layout := datetime[:10] + string(s[10]) + datetime[11:]
+	rest := s[len(datetime):]
+	if strings.HasPrefix(rest, ".") {
+		n := 1
+		for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+		layout += "." + strings.Repeat("0", n-1)
+		rest = rest[n:]
+	}
+	switch {
+	case rest == "":
+	case rest == "Z" || (len(rest) == 6 && rest[3] == ':'):
+		layout += "Z07:00"
+	case len(rest) == 5:
+		layout += "Z0700"
+	default:
+		return "", fmt.Errorf("unknown zone %q in timestamp %q", rest, s)
+	}
+	if _, err := time.Parse(layout, s); err != nil {
+		return "", err
+	}
+	return layout, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestTimeCodecMixedPrecision(t *testing.T) {
+	for _, test := range []struct {
+		in, want []string
+	}{
+		{
+			in:   []string{"2021-03-01T12:00:00Z", "2021-03-01T12:00:00.123456789Z", "2021-03-01T12:00:00.5Z", "2021-03-01T12:00:01Z"},
+			want: []string{"2021-03-01T12:00:00Z", "2021-03-01T12:00:00.123456789Z", "2021-03-01T12:00:00.500000000Z", "2021-03-01T12:00:01.000000000Z"},
+		},
+		{
+			in:   []string{"2021-03-01 12:00:00.250+01:00", "2021-03-01 12:00:00.5+01:00", "2021-03-01 12:00:00.000001+01:00"},
+			want: []string{"2021-03-01 12:00:00.250+01:00", "2021-03-01 12:00:00.500+01:00", "2021-03-01 12:00:00.000001+01:00"},
+		},
+	} {
+		c := newXCodec("time")
+		for i, s := range test.in {
+			x, err := c.parse(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.format(x); got != test.want[i] {
+				t.Errorf("%s formatted as %s, want %s", s, got, test.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package decim
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// CSVReader reads x and y values from two columns of delimited text one
+// row at a time, so files need not fit in memory.
+type CSVReader struct {
+	r          *csv.Reader
+	header     []string
+	xcol, ycol int
+	// first holds the first record of headerless data.
+	first []string
+}
+
+// NewCSVReader reads the header from r and selects the x and y columns,
+// given by name or by number starting at 1 as in the decimate command.
+// If the first row is numerical the data is considered headerless and
+// columns must be given by number. comma is the field delimiter.
+func NewCSVReader(r io.Reader, comma rune, xcol, ycol string) (*CSVReader, error) {
+	c := &CSVReader{r: csv.NewReader(r)}
+	c.r.Comma = comma
+	c.r.TrimLeadingSpace = true
+	c.r.ReuseRecord = true
+	first, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	first = append([]string(nil), first...)
+	headerless := true
+	for _, f := range first {
+		if _, err := strconv.ParseFloat(f, 64); err != nil {
+			headerless = false
+			break
+		}
+	}
+	if headerless {
+		c.first = first
+		c.header = make([]string, len(first))
+		for i := range c.header {
+			c.header[i] = strconv.Itoa(i + 1)
+		}
+	} else {
+		c.header = first
+	}
+	if c.xcol, err = c.column(xcol); err != nil {
+		return nil, err
+	}
+	if c.ycol, err = c.column(ycol); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// column returns the index of a column given by number or name.
+func (c *CSVReader) column(col string) (int, error) {
+	if n, err := strconv.Atoi(col); err == nil {
+		if n < 1 || n > len(c.header) {
+			return 0, fmt.Errorf("column number %d out of range. Have %d columns", n, len(c.header))
+		}
+		return n - 1, nil
+	}
+	for i, h := range c.header {
+		if h == col {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%s is not in columns: %v", col, c.header)
+}
+
+// Header returns the column names. Columns of headerless
+// data are named after their number starting at 1.
+func (c *CSVReader) Header() []string { return c.header }
+
+// Read returns the next row's x and y values. It returns io.EOF
+// when there are no more rows. Malformed rows and numbers
+// yield errors indicating their line in the input.
+func (c *CSVReader) Read() (x, y float64, err error) {
+	record := c.first
+	if record != nil {
+		c.first = nil
+	} else if record, err = c.r.Read(); err != nil {
+		return 0, 0, err
+	}
+	if x, err = c.parse(record, c.xcol); err != nil {
+		return 0, 0, err
+	}
+	y, err = c.parse(record, c.ycol)
+	return x, y, err
+}
+
+func (c *CSVReader) parse(record []string, col int) (float64, error) {
+	line, _ := c.r.FieldPos(0)
+	if col >= len(record) {
+		return 0, fmt.Errorf("line %d: missing column %q", line, c.header[col])
+	}
+	f, err := strconv.ParseFloat(record[col], 64)
+	if err != nil {
+		line, _ = c.r.FieldPos(col)
+		return 0, fmt.Errorf("line %d: column %q: %w", line, c.header[col], err)
+	}
+	return f, nil
+}
+
+// ReadCSV reads all rows of r with a CSVReader and returns an
+// XYer holding the parsed values of the selected columns.
+func ReadCSV(r io.Reader, comma rune, xcol, ycol string) (XYer, error) {
+	c, err := NewCSVReader(r, comma, xcol, ycol)
+	if err != nil {
+		return nil, err
+	}
+	v := &sliceXYer{}
+	for {
+		x, y, err := c.Read()
+		if errors.Is(err, io.EOF) {
+			return v, nil
+		} else if err != nil {
+			return nil, err
+		}
+		v.x, v.y = append(v.x, x), append(v.y, y)
+	}
+}
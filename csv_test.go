@@ -0,0 +1,49 @@
+package decim
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCSVReader(t *testing.T) {
+	const data = "time;a;b\n0;1;2\n1;3;4\n2;5;6\n"
+	c, err := NewCSVReader(strings.NewReader(data), ';', "time", "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := c.Header(); len(h) != 3 || h[2] != "b" {
+		t.Errorf("unexpected header %v", h)
+	}
+	for i := 0; ; i++ {
+		x, y, err := c.Read()
+		if errors.Is(err, io.EOF) {
+			if i != 3 {
+				t.Errorf("read %d rows, want 3", i)
+			}
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if x != float64(i) || y != float64(2*i+2) {
+			t.Errorf("row %d: got (%g, %g)", i, x, y)
+		}
+	}
+
+	xy, err := ReadCSV(strings.NewReader("1,2\n3,4\n"), ',', "2", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, y := xy.XY(1); xy.Len() != 2 || x != 4 || y != 3 {
+		t.Errorf("headerless read failed: len %d, (%g, %g)", xy.Len(), x, y)
+	}
+
+	_, err = ReadCSV(strings.NewReader("x,y\n1,2\n3,oops\n"), ',', "x", "y")
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected error at line 3, got %v", err)
+	}
+	if _, err = NewCSVReader(strings.NewReader("x,y\n1,2\n"), ',', "x", "z"); err == nil {
+		t.Error("expected error for missing column")
+	}
+}
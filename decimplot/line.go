@@ -0,0 +1,94 @@
+// Package decimplot provides gonum/plot plotters which decimate
+// data when drawn, according to the size of the target canvas.
+package decimplot
+
+import (
+	"math"
+
+	decim "github.com/soypat/go-decim"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// DefaultDPI is the resolution assumed when Line.DPI is zero. It is
+// higher than the 96 DPI of raster canvases so vector output can be zoomed.
+const DefaultDPI = 300
+
+// Line implements plot.Plotter and plot.DataRanger. It draws an XYer as a
+// line after decimating it so that the result is no more than half a pixel
+// away from a line through every point. Data is not copied, so huge
+// datasets are cheap to add to plots. Data x values must be strictly
+// increasing, otherwise all points are drawn. Axes are assumed linear.
+type Line struct {
+	decim.XYer
+	draw.LineStyle
+	// DPI is the resolution used to convert the canvas size to pixels.
+	DPI float64
+
+	xmin, xmax, ymin, ymax float64
+}
+
+var (
+	_ plot.Plotter     = (*Line)(nil)
+	_ plot.DataRanger  = (*Line)(nil)
+	_ plot.Thumbnailer = (*Line)(nil)
+)
+
+// NewLine returns a Line drawing xyer with the default line style.
+func NewLine(xyer decim.XYer) (*Line, error) {
+	l := &Line{
+		XYer:      xyer,
+		LineStyle: plotter.DefaultLineStyle,
+		xmin:      math.Inf(1), xmax: math.Inf(-1),
+		ymin: math.Inf(1), ymax: math.Inf(-1),
+	}
+	for i := 0; i < xyer.Len(); i++ {
+		x, y := xyer.XY(i)
+		if err := plotter.CheckFloats(x, y); err != nil {
+			return nil, err
+		}
+		l.xmin, l.xmax = math.Min(l.xmin, x), math.Max(l.xmax, x)
+		l.ymin, l.ymax = math.Min(l.ymin, y), math.Max(l.ymax, y)
+	}
+	return l, nil
+}
+
+// Plot decimates the data for the canvas size and draws it.
+// Implements the plot.Plotter interface.
+func (l *Line) Plot(c draw.Canvas, p *plot.Plot) {
+	dpi := l.DPI
+	if dpi == 0 {
+		dpi = DefaultDPI
+	}
+	vp := decim.Viewport{
+		XMin: p.X.Min, XMax: p.X.Max, YMin: p.Y.Min, YMax: p.Y.Max,
+		Width:  (c.Max.X - c.Min.X).Dots(dpi),
+		Height: (c.Max.Y - c.Min.Y).Dots(dpi),
+	}
+	data, err := vp.Decimate(l.XYer)
+	if err != nil {
+		data = l.XYer
+	}
+	trX, trY := p.Transforms(&c)
+	pts := make([]vg.Point, data.Len())
+	for i := range pts {
+		x, y := data.XY(i)
+		pts[i] = vg.Point{X: trX(x), Y: trY(y)}
+	}
+	c.StrokeLines(l.LineStyle, c.ClipLinesXY(pts)...)
+}
+
+// DataRange returns the minimum and maximum x and y values.
+// Implements the plot.DataRanger interface.
+func (l *Line) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return l.xmin, l.xmax, l.ymin, l.ymax
+}
+
+// Thumbnail draws a line in the given style for use in legends.
+// Implements the plot.Thumbnailer interface.
+func (l *Line) Thumbnail(c *draw.Canvas) {
+	y := c.Center().Y
+	c.StrokeLine2(l.LineStyle, c.Min.X, y, c.Max.X, y)
+}
@@ -0,0 +1,65 @@
+package decimplot
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+func TestLine(t *testing.T) {
+	const n = 100000
+	xys := make(plotter.XYs, n)
+	for i := range xys {
+		xys[i].X = float64(i)
+		xys[i].Y = math.Sin(float64(i) / 1000)
+	}
+	l, err := NewLine(xys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmin, xmax, ymin, ymax := l.DataRange()
+	if xmin != 0 || xmax != n-1 || ymin < -1 || ymax > 1 {
+		t.Errorf("unexpected data range %g %g %g %g", xmin, xmax, ymin, ymax)
+	}
+	// Points drawn for the line on canvases of increasing size and resolution.
+	drawn := func(width vg.Length, dpi float64) int {
+		l.DPI = dpi
+		p := plot.New()
+		p.Add(l)
+		p.Legend.Add("sin", l)
+		c := &strokeCounter{Canvas: vgimg.New(width, 5*vg.Centimeter)}
+		p.Draw(draw.New(c))
+		return c.longest
+	}
+	small := drawn(10*vg.Centimeter, 100)
+	if small < 10 || small >= n/10 {
+		t.Errorf("line drawn with %d of %d points", small, n)
+	}
+	if hires := drawn(10*vg.Centimeter, 600); hires <= small {
+		t.Errorf("higher DPI drew %d points, not more than %d", hires, small)
+	}
+	if wide := drawn(40*vg.Centimeter, 100); wide <= small {
+		t.Errorf("wider canvas drew %d points, not more than %d", wide, small)
+	}
+	if _, err := NewLine(plotter.XYs{{X: 0, Y: math.NaN()}}); err == nil {
+		t.Error("expected error for NaN data")
+	}
+}
+
+// strokeCounter records the number of points of the longest path stroked.
+type strokeCounter struct {
+	*vgimg.Canvas
+	longest int
+}
+
+func (c *strokeCounter) Stroke(path vg.Path) {
+	if len(path) > c.longest {
+		c.longest = len(path)
+	}
+	c.Canvas.Stroke(path)
+}
@@ -0,0 +1,72 @@
+package decim
+
+import (
+	"math"
+	"time"
+)
+
+// Int64XYer is implemented by data whose x values are integers that
+// may not be exactly representable as float64, such as Unix nanosecond
+// timestamps which exceed 2^53.
+type Int64XYer interface {
+	XYInt64(i int) (x int64, y float64)
+	Len() int
+}
+
+// EpochXYer adapts an Int64XYer to the XYer interface by subtracting
+// Epoch from the x values. As long as x values lie within 2^53 of the
+// epoch (around 104 days for nanosecond timestamps) the resulting float64
+// x values are exact and may be converted back without loss with Int64.
+type EpochXYer struct {
+	Epoch int64
+	Data  Int64XYer
+}
+
+// NewEpochXYer returns an EpochXYer with the epoch set to data's first x value.
+func NewEpochXYer(data Int64XYer) *EpochXYer {
+	if data == nil {
+		panic("got nil data")
+	}
+	e := &EpochXYer{Data: data}
+	if data.Len() > 0 {
+		e.Epoch, _ = data.XYInt64(0)
+	}
+	return e
+}
+
+func (e *EpochXYer) XY(i int) (x, y float64) {
+	xi, y := e.Data.XYInt64(i)
+	return float64(xi - e.Epoch), y
+}
+
+func (e *EpochXYer) Len() int { return e.Data.Len() }
+
+// Int64 converts an x value obtained from e, or from a Sampler
+// reading from e, back to its original integer value.
+func (e *EpochXYer) Int64(x float64) int64 {
+	return e.Epoch + int64(math.Round(x))
+}
+
+// Time converts an x value obtained from e to a time
+// by interpreting the original integer as Unix nanoseconds.
+func (e *EpochXYer) Time(x float64) time.Time {
+	return time.Unix(0, e.Int64(x))
+}
+
+// Timestamps is an Int64XYer of Unix nanosecond timestamps and their values.
+type Timestamps struct {
+	T []int64
+	Y []float64
+}
+
+// Append adds a sample at time t.
+func (ts *Timestamps) Append(t time.Time, y float64) {
+	ts.T = append(ts.T, t.UnixNano())
+	ts.Y = append(ts.Y, y)
+}
+
+func (ts *Timestamps) XYInt64(i int) (x int64, y float64) {
+	return ts.T[i], ts.Y[i]
+}
+
+func (ts *Timestamps) Len() int { return len(ts.T) }
@@ -0,0 +1,46 @@
+package decim
+
+import (
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEpochXYer(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 1, time.UTC)
+	ts := &Timestamps{}
+	for i := 0; i < 1000; i++ {
+		// Nanosecond spacing is lost when timestamps are converted directly to float64.
+		ts.Append(start.Add(time.Duration(i*3+i%7)), math.Sin(float64(i)/50))
+	}
+	e := NewEpochXYer(ts)
+	if e.Epoch != start.UnixNano() {
+		t.Fatalf("expected epoch %d, got %d", start.UnixNano(), e.Epoch)
+	}
+	inData := make(map[int64]bool, ts.Len())
+	for _, v := range ts.T {
+		inData[v] = true
+	}
+	s := NewSampler(e, 0.05)
+	var n int
+	var x float64
+	var err error
+	for ; err == nil; x, _, err = s.Next() {
+		if !inData[e.Int64(x)] {
+			t.Fatalf("decimated timestamp %d not in original data", e.Int64(x))
+		}
+		n++
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Fatal(err)
+	}
+	if n >= ts.Len() {
+		t.Fatal("did not decimate succesfully")
+	}
+	last, _ := e.XY(ts.Len() - 1)
+	if !e.Time(last).Equal(time.Unix(0, ts.T[ts.Len()-1])) {
+		t.Error("time conversion mismatch")
+	}
+}
@@ -1,8 +1,25 @@
 module github.com/soypat/go-decim
 
-go 1.15
+go 1.17
 
 require (
-	github.com/dgryski/go-lttb v0.0.0-20210302151804-4a713d71336c
+	github.com/BurntSushi/toml v0.3.1
+	github.com/klauspost/compress v1.13.6
 	github.com/spf13/cobra v1.1.1
+	github.com/spf13/pflag v1.0.5
+	github.com/ulikunitz/xz v0.5.10
+	gonum.org/v1/plot v0.10.0
+	gopkg.in/yaml.v2 v2.2.8
+)
+
+require (
+	github.com/ajstarks/svgo v0.0.0-20210923152817-c3b6e2f0c527 // indirect
+	github.com/fogleman/gg v1.3.0 // indirect
+	github.com/go-fonts/liberation v0.2.0 // indirect
+	github.com/go-latex/latex v0.0.0-20210823091927-c0d11ff05a81 // indirect
+	github.com/go-pdf/fpdf v0.5.0 // indirect
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d // indirect
+	golang.org/x/text v0.3.6 // indirect
 )
@@ -0,0 +1,160 @@
+package decim
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// InterpMode selects how an Interpolator evaluates y between data points.
+type InterpMode int
+
+const (
+	// InterpLinear joins points with straight lines, as plotting tools
+	// draw decimated data.
+	InterpLinear InterpMode = iota
+	// InterpStep holds each point's y value until the next point.
+	InterpStep
+	// InterpMonotoneCubic uses Fritsch–Carlson monotone cubic Hermite
+	// splines, which are smooth and do not overshoot the data.
+	InterpMonotoneCubic
+)
+
+// Interpolator reconstructs y values at arbitrary x from decimated
+// data such as the output of Sampler.XYer. Evaluating outside
+// the range of the data returns NaN.
+type Interpolator struct {
+	x, y []float64
+	// m holds tangents for InterpMonotoneCubic.
+	m    []float64
+	mode InterpMode
+}
+
+// NewInterpolator copies xyer's points and returns an Interpolator
+// evaluating them with mode. x values must be strictly increasing.
+func NewInterpolator(xyer XYer, mode InterpMode) (*Interpolator, error) {
+	if xyer == nil {
+		panic("got nil xyer")
+	}
+	n := xyer.Len()
+	if n == 0 {
+		return nil, errors.New("need at least one point to interpolate")
+	}
+	it := &Interpolator{x: make([]float64, n), y: make([]float64, n), mode: mode}
+	for i := 0; i < n; i++ {
+		it.x[i], it.y[i] = xyer.XY(i)
+		if i > 0 && !(it.x[i] > it.x[i-1]) {
+			return nil, errors.New("x values not strictly increasing")
+		}
+	}
+	switch mode {
+	case InterpLinear, InterpStep:
+	case InterpMonotoneCubic:
+		it.m = monotoneTangents(it.x, it.y)
+	default:
+		return nil, errors.New("unknown interpolation mode")
+	}
+	return it, nil
+}
+
+// At returns the interpolated y value at x.
+func (it *Interpolator) At(x float64) float64 {
+	return it.at(x, it.segment(x, -1))
+}
+
+// AtAll evaluates the interpolator at every value of xs, storing
+// results in dst which is grown if needed and returned. Lookup is
+// fastest when xs is sorted.
+func (it *Interpolator) AtAll(dst, xs []float64) []float64 {
+	if cap(dst) < len(xs) {
+		dst = make([]float64, len(xs))
+	}
+	dst = dst[:len(xs)]
+	seg := -1
+	for i, x := range xs {
+		seg = it.segment(x, seg)
+		dst[i] = it.at(x, seg)
+	}
+	return dst
+}
+
+// Len returns the number of points the interpolator holds.
+func (it *Interpolator) Len() int { return len(it.x) }
+
+// XY returns the i'th point the interpolator holds.
+func (it *Interpolator) XY(i int) (x, y float64) { return it.x[i], it.y[i] }
+
+// segment returns i such that x[i] <= x < x[i+1], clamped to the
+// last segment for the last point. -1 is returned out of range.
+// hint is the previous segment found, which is checked before searching.
+func (it *Interpolator) segment(x float64, hint int) int {
+	n := len(it.x)
+	if !(x >= it.x[0]) || !(x <= it.x[n-1]) {
+		return -1
+	}
+	if n == 1 {
+		return 0
+	}
+	if hint >= 0 && hint < n-1 && x >= it.x[hint] && (x < it.x[hint+1] || hint == n-2) {
+		return hint
+	}
+	i := sort.Search(n, func(i int) bool { return it.x[i] > x }) - 1
+	if i == n-1 {
+		i--
+	}
+	return i
+}
+
+func (it *Interpolator) at(x float64, i int) float64 {
+	if i < 0 {
+		return math.NaN()
+	}
+	if len(it.x) == 1 || x == it.x[i] {
+		return it.y[i]
+	}
+	x0, x1, y0, y1 := it.x[i], it.x[i+1], it.y[i], it.y[i+1]
+	switch it.mode {
+	case InterpStep:
+		if x == x1 {
+			return y1
+		}
+		return y0
+	case InterpMonotoneCubic:
+		h := x1 - x0
+		t := (x - x0) / h
+		t2, t3 := t*t, t*t*t
+		return (2*t3-3*t2+1)*y0 + (t3-2*t2+t)*h*it.m[i] + (-2*t3+3*t2)*y1 + (t3-t2)*h*it.m[i+1]
+	}
+	return y0 + (x-x0)*(y1-y0)/(x1-x0)
+}
+
+// monotoneTangents computes Fritsch–Carlson tangents.
+func monotoneTangents(x, y []float64) []float64 {
+	n := len(x)
+	m := make([]float64, n)
+	if n < 2 {
+		return m
+	}
+	delta := make([]float64, n-1)
+	for i := range delta {
+		delta[i] = (y[i+1] - y[i]) / (x[i+1] - x[i])
+	}
+	m[0], m[n-1] = delta[0], delta[n-2]
+	for i := 1; i < n-1; i++ {
+		if delta[i-1]*delta[i] > 0 {
+			m[i] = (delta[i-1] + delta[i]) / 2
+		}
+	}
+	for i, d := range delta {
+		if d == 0 {
+			m[i], m[i+1] = 0, 0
+			continue
+		}
+		a, b := m[i]/d, m[i+1]/d
+		if s := a*a + b*b; s > 9 {
+			tau := 3 / math.Sqrt(s)
+			m[i], m[i+1] = tau*a*d, tau*b*d
+		}
+	}
+	return m
+}
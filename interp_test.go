@@ -0,0 +1,57 @@
+package decim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestInterpolator(t *testing.T) {
+	v := &sliceXYer{x: []float64{0, 1, 2, 4}, y: []float64{0, 2, 2, 6}}
+	for _, test := range []struct {
+		mode InterpMode
+		x, y []float64
+	}{
+		{mode: InterpLinear, x: []float64{-1, 0, 0.5, 1.5, 3, 4, 5}, y: []float64{math.NaN(), 0, 1, 2, 4, 6, math.NaN()}},
+		{mode: InterpStep, x: []float64{0, 0.5, 1, 3, 4}, y: []float64{0, 0, 2, 2, 6}},
+		{mode: InterpMonotoneCubic, x: []float64{0, 1, 1.5, 2, 4}, y: []float64{0, 2, 2, 2, 6}},
+	} {
+		it, err := NewInterpolator(v, test.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := it.AtAll(nil, test.x)
+		for i, want := range test.y {
+			if y := it.At(test.x[i]); !sameFloat(y, want) || !sameFloat(got[i], want) {
+				t.Errorf("mode %d: At(%g)=%g, AtAll gave %g, want %g", test.mode, test.x[i], y, got[i], want)
+			}
+		}
+	}
+}
+
+func TestInterpolatorMonotone(t *testing.T) {
+	c := readTestCSV(t)
+	s := NewSampler(c, 1e-3)
+	it, err := NewInterpolator(s.XYer(), InterpMonotoneCubic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xs := make([]float64, 1000)
+	x0, _ := it.XY(0)
+	xn, _ := it.XY(it.Len() - 1)
+	for i := range xs {
+		xs[i] = x0 + (xn-x0)*rand.Float64()
+	}
+	ys := it.AtAll(nil, xs)
+	for i, x := range xs {
+		seg := it.segment(x, -1)
+		lo, hi := math.Min(it.y[seg], it.y[seg+1]), math.Max(it.y[seg], it.y[seg+1])
+		if ys[i] != it.At(x) || ys[i] < lo || ys[i] > hi {
+			t.Fatalf("monotone cubic overshoot at x=%g: %g not in [%g, %g]", x, ys[i], lo, hi)
+		}
+	}
+}
+
+func sameFloat(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
@@ -0,0 +1,332 @@
+package decim
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// MAT-file Level 5 data types.
+const (
+	miINT8       = 1
+	miUINT8      = 2
+	miINT16      = 3
+	miUINT16     = 4
+	miINT32      = 5
+	miUINT32     = 6
+	miSINGLE     = 7
+	miDOUBLE     = 9
+	miINT64      = 12
+	miUINT64     = 13
+	miMATRIX     = 14
+	miCOMPRESSED = 15
+)
+
+// MAT-file array classes.
+const (
+	mxDOUBLE = 6
+	mxUINT64 = 15
+)
+
+const matHeaderLen = 128
+
+// MatVar is a numeric variable of a MATLAB Level 5 MAT-file. Values of
+// any numeric class are converted to float64 when read.
+type MatVar struct {
+	Name string
+	// Dims are the dimensions of the array, at least two.
+	Dims []int
+	// Data holds the real part of values in column major order.
+	Data []float64
+	// Imag holds the imaginary part of complex arrays and is nil otherwise.
+	Imag []float64
+}
+
+// Column returns a copy of column j of a 2-D array.
+func (v *MatVar) Column(j int) []float64 {
+	if len(v.Dims) != 2 {
+		panic("need 2-D array")
+	}
+	rows := v.Dims[0]
+	return append([]float64(nil), v.Data[j*rows:(j+1)*rows]...)
+}
+
+// ReadMAT reads the numeric arrays of a Level 5 MAT-file as written by
+// MATLAB with the -v6 and -v7 options, compressed or not. Variables of
+// other classes such as cells, structs or sparse arrays are skipped.
+func ReadMAT(r io.Reader) ([]*MatVar, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	var header [matHeaderLen]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("reading MAT-file header: %w", err)
+	}
+	var order binary.ByteOrder
+	switch string(header[126:]) {
+	case "IM":
+		order = binary.LittleEndian
+	case "MI":
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("not a Level 5 MAT-file")
+	}
+	if version := order.Uint16(header[124:]); version != 0x0100 {
+		return nil, fmt.Errorf("unsupported MAT-file version %#x. v7.3 files are HDF5", version)
+	}
+	var vars []*MatVar
+	for {
+		typ, data, err := readMatElement(br, order)
+		if errors.Is(err, io.EOF) {
+			return vars, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if typ == miCOMPRESSED {
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			typ, data, err = readMatElement(zr, order)
+			zr.Close()
+			if err != nil {
+				return nil, fmt.Errorf("reading compressed element: %w", err)
+			}
+		}
+		if typ != miMATRIX {
+			continue
+		}
+		v, err := parseMatMatrix(data, order)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			vars = append(vars, v)
+		}
+	}
+}
+
+// readMatElement reads a data element's type and data. It returns io.EOF
+// only if r ends before the element starts.
+func readMatElement(r io.Reader, order binary.ByteOrder) (typ uint32, data []byte, err error) {
+	var tag [8]byte
+	if _, err := io.ReadFull(r, tag[:4]); err != nil {
+		return 0, nil, err
+	}
+	typ = order.Uint32(tag[:])
+	if small := typ >> 16; small != 0 {
+		// Small data element format packs up to 4 bytes into the tag.
+		if _, err := io.ReadFull(r, tag[4:]); err != nil || small > 4 {
+			return 0, nil, errors.New("bad MAT-file small data element")
+		}
+		return typ & 0xffff, tag[4 : 4+small], nil
+	}
+	if _, err := io.ReadFull(r, tag[4:]); err != nil {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	n := int64(order.Uint32(tag[4:]))
+	data, err = ioutil.ReadAll(io.LimitReader(r, n))
+	if err == nil && int64(len(data)) < n {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if typ != miCOMPRESSED && n%8 != 0 {
+		// Padding to 64 bit boundary may be missing at the end of file.
+		io.ReadFull(r, tag[:8-n%8])
+	}
+	return typ, data, nil
+}
+
+// parseMatMatrix parses the data of a miMATRIX element. It returns
+// a nil MatVar if the matrix is not numeric.
+func parseMatMatrix(data []byte, order binary.ByteOrder) (*MatVar, error) {
+	r := bytes.NewReader(data)
+	sub := func() (uint32, []byte, error) {
+		typ, b, err := readMatElement(r, order)
+		if err != nil {
+			return 0, nil, fmt.Errorf("reading MAT-file array: %w", err)
+		}
+		return typ, b, nil
+	}
+	_, flags, err := sub()
+	if err != nil {
+		return nil, err
+	}
+	if len(flags) < 4 {
+		return nil, errors.New("bad MAT-file array flags")
+	}
+	class := order.Uint32(flags) & 0xff
+	complexData := order.Uint32(flags)&0x0800 != 0
+	if class < mxDOUBLE || class > mxUINT64 {
+		return nil, nil
+	}
+	typ, dims, err := sub()
+	if err != nil {
+		return nil, err
+	}
+	v := &MatVar{}
+	size := 1
+	for i := 0; i+4 <= len(dims) && typ == miINT32; i += 4 {
+		d := int(int32(order.Uint32(dims[i:])))
+		if d < 0 {
+			return nil, errors.New("negative MAT-file array dimension")
+		}
+		v.Dims = append(v.Dims, d)
+		size *= d
+	}
+	if len(v.Dims) < 2 {
+		return nil, errors.New("bad MAT-file array dimensions")
+	}
+	_, name, err := sub()
+	if err != nil {
+		return nil, err
+	}
+	v.Name = string(name)
+	if v.Data, err = readMatNumbers(sub, order, size); err != nil {
+		return nil, fmt.Errorf("%s: %w", v.Name, err)
+	}
+	if complexData {
+		if v.Imag, err = readMatNumbers(sub, order, size); err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+	}
+	return v, nil
+}
+
+func readMatNumbers(sub func() (uint32, []byte, error), order binary.ByteOrder, size int) ([]float64, error) {
+	typ, b, err := sub()
+	if err != nil {
+		return nil, err
+	}
+	dec := numDecoder{order: order}
+	switch typ {
+	case miINT8, miINT16, miINT32, miINT64:
+		dec.kind = 'i'
+	case miUINT8, miUINT16, miUINT32, miUINT64:
+		dec.kind = 'u'
+	case miSINGLE, miDOUBLE:
+		dec.kind = 'f'
+	default:
+		return nil, fmt.Errorf("unsupported MAT-file data type %d", typ)
+	}
+	switch typ {
+	case miINT8, miUINT8:
+		dec.size = 1
+	case miINT16, miUINT16:
+		dec.size = 2
+	case miINT32, miUINT32, miSINGLE:
+		dec.size = 4
+	default:
+		dec.size = 8
+	}
+	if len(b) != size*dec.size {
+		return nil, fmt.Errorf("%d bytes of data for %d values", len(b), size)
+	}
+	data := make([]float64, size)
+	dec.decodeAll(data, b)
+	return data, nil
+}
+
+// WriteMAT writes vars to w as a compressed Level 5 MAT-file of double arrays,
+// which MATLAB reads with load. Variable names must be valid MATLAB identifiers.
+func WriteMAT(w io.Writer, vars []*MatVar) error {
+	var header [matHeaderLen]byte
+	text := "MATLAB 5.0 MAT-file, Platform: GLNXA64, Created by: go-decim"
+	copy(header[:], text+strings.Repeat(" ", 116-len(text)))
+	binary.LittleEndian.PutUint16(header[124:], 0x0100)
+	copy(header[126:], "IM")
+	bw := bufio.NewWriterSize(w, 1<<16)
+	bw.Write(header[:])
+	var matrix, compressed bytes.Buffer
+	for _, v := range vars {
+		if err := checkMatVar(v); err != nil {
+			return err
+		}
+		matrix.Reset()
+		writeMatMatrix(&matrix, v)
+		compressed.Reset()
+		zw := zlib.NewWriter(&compressed)
+		writeMatElement(zw, miMATRIX, matrix.Bytes())
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		writeMatElement(bw, miCOMPRESSED, compressed.Bytes())
+	}
+	return bw.Flush()
+}
+
+func checkMatVar(v *MatVar) error {
+	size := 1
+	for _, d := range v.Dims {
+		size *= d
+	}
+	switch {
+	case len(v.Dims) < 2:
+		return fmt.Errorf("variable %s needs at least 2 dimensions", v.Name)
+	case size != len(v.Data) || v.Imag != nil && size != len(v.Imag):
+		return fmt.Errorf("variable %s dimensions %v do not match %d values", v.Name, v.Dims, len(v.Data))
+	case !validMatName(v.Name):
+		return fmt.Errorf("invalid MATLAB variable name %q", v.Name)
+	}
+	return nil
+}
+
+// validMatName reports whether name is a valid MATLAB variable name.
+func validMatName(name string) bool {
+	if name == "" || len(name) > 63 {
+		return false
+	}
+	for i, c := range name {
+		letter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if !letter && (i == 0 || c != '_' && (c < '0' || c > '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeMatMatrix(w *bytes.Buffer, v *MatVar) {
+	flags := make([]byte, 8)
+	f := uint32(mxDOUBLE)
+	if v.Imag != nil {
+		f |= 0x0800
+	}
+	binary.LittleEndian.PutUint32(flags, f)
+	writeMatElement(w, miUINT32, flags)
+	dims := make([]byte, 4*len(v.Dims))
+	for i, d := range v.Dims {
+		binary.LittleEndian.PutUint32(dims[4*i:], uint32(d))
+	}
+	writeMatElement(w, miINT32, dims)
+	writeMatElement(w, miINT8, []byte(v.Name))
+	for _, data := range [][]float64{v.Data, v.Imag} {
+		if data == nil {
+			continue
+		}
+		b := make([]byte, 8*len(data))
+		for i, f := range data {
+			binary.LittleEndian.PutUint64(b[8*i:], math.Float64bits(f))
+		}
+		writeMatElement(w, miDOUBLE, b)
+	}
+}
+
+// writeMatElement writes a little endian data element, padded to a 64
+// bit boundary unless compressed.
+func writeMatElement(w io.Writer, typ uint32, data []byte) {
+	var tag [8]byte
+	binary.LittleEndian.PutUint32(tag[:], typ)
+	binary.LittleEndian.PutUint32(tag[4:], uint32(len(data)))
+	w.Write(tag[:])
+	w.Write(data)
+	if pad := len(data) % 8; pad != 0 && typ != miCOMPRESSED {
+		w.Write(make([]byte, 8-pad))
+	}
+}
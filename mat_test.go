@@ -0,0 +1,68 @@
+package decim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestMAT(t *testing.T) {
+	vars := []*MatVar{
+		{Name: "t", Dims: []int{3, 1}, Data: []float64{0, 0.5, 1}},
+		{Name: "data_2", Dims: []int{3, 2}, Data: []float64{1, 2, 3, 4, 5, 6}, Imag: []float64{0, 0, 0, -1, 0, 1}},
+	}
+	var buf bytes.Buffer
+	if err := WriteMAT(&buf, vars); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadMAT(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != "t" || got[0].Data[1] != 0.5 || got[1].Imag[3] != -1 {
+		t.Fatalf("bad round trip: %+v", got)
+	}
+	if c := got[1].Column(1); len(c) != 3 || c[0] != 4 || c[2] != 6 {
+		t.Errorf("bad column %v", c)
+	}
+	if err := WriteMAT(&buf, []*MatVar{{Name: "1x", Dims: []int{1, 1}, Data: []float64{1}}}); err == nil {
+		t.Error("expected invalid name error")
+	}
+
+	// Uncompressed big endian file with a cell array, which is
+	// skipped, and an int16 row vector with a small element name.
+	buf.Reset()
+	header := make([]byte, matHeaderLen)
+	copy(header, "MATLAB 5.0 MAT-file")
+	binary.BigEndian.PutUint16(header[124:], 0x0100)
+	copy(header[126:], "MI")
+	buf.Write(header)
+	element := func(typ uint32, data []byte) []byte {
+		var b bytes.Buffer
+		binary.Write(&b, binary.BigEndian, [2]uint32{typ, uint32(len(data))})
+		b.Write(data)
+		b.Write(make([]byte, (8-len(data)%8)%8))
+		return b.Bytes()
+	}
+	small := func(typ uint32, data string) []byte {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint32(b, uint32(len(data))<<16|typ)
+		copy(b[4:], data)
+		return b
+	}
+	var cell, vec bytes.Buffer
+	cell.Write(element(miUINT32, []byte{0, 0, 0, 1, 0, 0, 0, 0}))
+	vec.Write(element(miUINT32, []byte{0, 0, 0, 10, 0, 0, 0, 0}))
+	vec.Write(element(miINT32, []byte{0, 0, 0, 1, 0, 0, 0, 3}))
+	vec.Write(small(miINT8, "y"))
+	vec.Write(element(miINT16, []byte{0, 1, 0xff, 0xfe, 0, 3}))
+	buf.Write(element(miMATRIX, cell.Bytes()))
+	buf.Write(element(miMATRIX, vec.Bytes()))
+	got, err = ReadMAT(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "y" || got[0].Dims[1] != 3 || got[0].Data[1] != -2 {
+		t.Errorf("bad big endian file contents %+v", got)
+	}
+}
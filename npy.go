@@ -0,0 +1,290 @@
+package decim
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// npyMagic starts every NumPy .npy file.
+const npyMagic = "\x93NUMPY"
+
+// NPY is a numerical NumPy array. Values of any integer, boolean or
+// floating point dtype are converted to float64 when read.
+type NPY struct {
+	Shape []int
+	// FortranOrder is set when Data is stored in column major order.
+	FortranOrder bool
+	Data         []float64
+}
+
+// Column returns a copy of column j of a 2-D array.
+func (a *NPY) Column(j int) []float64 {
+	if len(a.Shape) != 2 {
+		panic("need 2-D array")
+	}
+	rows, cols := a.Shape[0], a.Shape[1]
+	c := make([]float64, rows)
+	for i := range c {
+		if a.FortranOrder {
+			c[i] = a.Data[j*rows+i]
+		} else {
+			c[i] = a.Data[i*cols+j]
+		}
+	}
+	return c
+}
+
+// NPYReader reads the values of a .npy file in storage order
+// without loading them all into memory.
+type NPYReader struct {
+	r         *bufio.Reader
+	dec       numDecoder
+	shape     []int
+	fortran   bool
+	remaining int
+	buf       []byte
+}
+
+// NewNPYReader reads the .npy header from r.
+func NewNPYReader(r io.Reader) (*NPYReader, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	pre := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(br, pre); err != nil {
+		return nil, err
+	}
+	if string(pre[:len(npyMagic)]) != npyMagic {
+		return nil, errors.New("not a .npy file")
+	}
+	var hlen int
+	switch major := pre[len(npyMagic)]; major {
+	case 1:
+		var b [2]byte
+		if _, err := io.ReadFull(br, b[:]); err != nil {
+			return nil, err
+		}
+		hlen = int(binary.LittleEndian.Uint16(b[:]))
+	case 2, 3:
+		var b [4]byte
+		if _, err := io.ReadFull(br, b[:]); err != nil {
+			return nil, err
+		}
+		hlen = int(binary.LittleEndian.Uint32(b[:]))
+	default:
+		return nil, fmt.Errorf("unsupported .npy version %d", major)
+	}
+	if hlen > 1<<20 {
+		return nil, errors.New(".npy header too long")
+	}
+	header := make([]byte, hlen)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	n := &NPYReader{r: br}
+	if err := n.parseHeader(string(header)); err != nil {
+		return nil, err
+	}
+	n.remaining = 1
+	for _, d := range n.shape {
+		n.remaining *= d
+	}
+	return n, nil
+}
+
+// parseHeader parses the Python dictionary literal describing the array,
+// i.e: {'descr': '<f8', 'fortran_order': False, 'shape': (3, 2), }
+func (n *NPYReader) parseHeader(h string) error {
+	descr, err := npyField(h, "descr")
+	if err != nil {
+		return err
+	}
+	descr = strings.Trim(descr, "'\"")
+	if len(descr) < 3 {
+		return fmt.Errorf("bad .npy dtype %q", descr)
+	}
+	n.dec.order = binary.LittleEndian
+	if descr[0] == '>' {
+		n.dec.order = binary.BigEndian
+	}
+	n.dec.kind = descr[1]
+	n.dec.size, err = strconv.Atoi(descr[2:])
+	if err != nil || !n.dec.valid() {
+		return fmt.Errorf("unsupported .npy dtype %q", descr)
+	}
+	fortran, err := npyField(h, "fortran_order")
+	if err != nil {
+		return err
+	}
+	n.fortran = fortran == "True"
+	shape, err := npyField(h, "shape")
+	if err != nil {
+		return err
+	}
+	for _, d := range strings.Split(strings.Trim(shape, "()"), ",") {
+		if d = strings.TrimSpace(d); d == "" {
+			continue
+		}
+		v, err := strconv.Atoi(d)
+		if err != nil || v < 0 {
+			return fmt.Errorf("bad .npy shape %q", shape)
+		}
+		n.shape = append(n.shape, v)
+	}
+	return nil
+}
+
+// npyField returns the literal value of key in a .npy header dictionary.
+func npyField(h, key string) (string, error) {
+	i := strings.Index(h, "'"+key+"'")
+	if i < 0 {
+		return "", fmt.Errorf(".npy header missing %q", key)
+	}
+	v := strings.TrimSpace(h[i+len(key)+2:])
+	if !strings.HasPrefix(v, ":") {
+		return "", fmt.Errorf("bad .npy header %q", h)
+	}
+	v = strings.TrimSpace(v[1:])
+	end := strings.IndexAny(v, ",}")
+	if strings.HasPrefix(v, "(") {
+		end = strings.Index(v, ")") + 1
+	}
+	if end <= 0 {
+		return "", fmt.Errorf("bad .npy header %q", h)
+	}
+	return v[:end], nil
+}
+
+// Shape returns the dimensions of the array.
+func (n *NPYReader) Shape() []int { return n.shape }
+
+// FortranOrder reports whether values are stored in column major order.
+func (n *NPYReader) FortranOrder() bool { return n.fortran }
+
+// Read reads the next len(dst) values in storage order, which for a C
+// ordered 2-D array with len(dst) columns is the next row. It returns
+// io.EOF when no values remain.
+func (n *NPYReader) Read(dst []float64) error {
+	if n.remaining == 0 {
+		return io.EOF
+	}
+	if len(dst) > n.remaining {
+		return io.ErrUnexpectedEOF
+	}
+	size := len(dst) * n.dec.size
+	if cap(n.buf) < size {
+		n.buf = make([]byte, size)
+	}
+	if _, err := io.ReadFull(n.r, n.buf[:size]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	n.dec.decodeAll(dst, n.buf)
+	n.remaining -= len(dst)
+	return nil
+}
+
+// ReadNPY reads a whole .npy file from r.
+func ReadNPY(r io.Reader) (*NPY, error) {
+	n, err := NewNPYReader(r)
+	if err != nil {
+		return nil, err
+	}
+	a := &NPY{Shape: n.shape, FortranOrder: n.fortran}
+	const chunk = 1 << 16
+	for n.remaining > 0 {
+		m := n.remaining
+		if m > chunk {
+			m = chunk
+		}
+		start := len(a.Data)
+		a.Data = append(a.Data, make([]float64, m)...)
+		if err := n.Read(a.Data[start:]); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// WriteNPY writes a to w as a little endian float64 .npy version 1.0 file.
+func WriteNPY(w io.Writer, a *NPY) error {
+	size := 1
+	shape := make([]string, len(a.Shape))
+	for i, d := range a.Shape {
+		size *= d
+		shape[i] = strconv.Itoa(d)
+	}
+	if size != len(a.Data) {
+		return fmt.Errorf("shape %v does not match %d values", a.Shape, len(a.Data))
+	}
+	shapeStr := strings.Join(shape, ", ")
+	if len(shape) == 1 {
+		shapeStr += ","
+	}
+	fortran := "False"
+	if a.FortranOrder {
+		fortran = "True"
+	}
+	header := fmt.Sprintf("{'descr': '<f8', 'fortran_order': %s, 'shape': (%s), }", fortran, shapeStr)
+	// Header is padded with spaces and a newline so data is 64 byte aligned.
+	prefix := len(npyMagic) + 4
+	header += strings.Repeat(" ", 63-(prefix+len(header))%64) + "\n"
+	bw := bufio.NewWriterSize(w, 1<<16)
+	bw.WriteString(npyMagic + "\x01\x00")
+	binary.Write(bw, binary.LittleEndian, uint16(len(header)))
+	bw.WriteString(header)
+	var buf [8]byte
+	for _, v := range a.Data {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		bw.Write(buf[:])
+	}
+	return bw.Flush()
+}
+
+// ReadNPZ reads the arrays of a NumPy .npz archive, compressed or not,
+// and returns them with their names in archive order.
+func ReadNPZ(r io.ReaderAt, size int64) (names []string, arrays []*NPY, err error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		a, err := ReadNPY(rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		names = append(names, strings.TrimSuffix(f.Name, ".npy"))
+		arrays = append(arrays, a)
+	}
+	return names, arrays, nil
+}
+
+// WriteNPZ writes arrays to w as a compressed .npz archive, as numpy.savez_compressed does.
+func WriteNPZ(w io.Writer, names []string, arrays []*NPY) error {
+	if len(names) != len(arrays) {
+		return errors.New("need a name for each array")
+	}
+	zw := zip.NewWriter(w)
+	for i, a := range arrays {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: names[i] + ".npy", Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if err := WriteNPY(fw, a); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,102 @@
+package decim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestNPY(t *testing.T) {
+	a := &NPY{Shape: []int{3, 2}, Data: []float64{0, 1, 2, 3, 4, 5.5}}
+	var buf bytes.Buffer
+	if err := WriteNPY(&buf, a); err != nil {
+		t.Fatal(err)
+	}
+	if (buf.Len()-len(a.Data)*8)%64 != 0 {
+		t.Errorf("header not 64 byte aligned: %d bytes", buf.Len()-len(a.Data)*8)
+	}
+	n, err := NewNPYReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := n.Shape(); len(s) != 2 || s[0] != 3 || s[1] != 2 || n.FortranOrder() {
+		t.Fatalf("bad header: shape %v fortran %v", s, n.FortranOrder())
+	}
+	row := make([]float64, 2)
+	for i := 0; i < 3; i++ {
+		if err := n.Read(row); err != nil {
+			t.Fatal(err)
+		}
+		if row[0] != a.Data[2*i] || row[1] != a.Data[2*i+1] {
+			t.Errorf("row %d: got %v", i, row)
+		}
+	}
+	if err := n.Read(row); err == nil {
+		t.Error("expected EOF")
+	}
+
+	// Big endian int32 array in Fortran order as written by NumPy.
+	header := "{'descr': '>i4', 'fortran_order': True, 'shape': (2, 3), }"
+	header += string(bytes.Repeat([]byte{' '}, 63-(10+len(header))%64)) + "\n"
+	buf.Reset()
+	buf.WriteString(npyMagic + "\x01\x00")
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	binary.Write(&buf, binary.BigEndian, []int32{1, 2, 3, 4, 5, -6})
+	got, err := ReadNPY(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := got.Column(2); c[0] != 5 || c[1] != -6 {
+		t.Errorf("bad fortran order column: %v", c)
+	}
+
+	buf.Reset()
+	if err := WriteNPZ(&buf, []string{"x", "y"}, []*NPY{{Shape: []int{2}, Data: []float64{1, 2}}, a}); err != nil {
+		t.Fatal(err)
+	}
+	names, arrays, err := ReadNPZ(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[1] != "y" || arrays[1].Data[5] != 5.5 || len(arrays[0].Shape) != 1 {
+		t.Errorf("bad npz contents %v %v", names, arrays)
+	}
+}
+
+func TestBinary(t *testing.T) {
+	x, y := []float64{0, 1, 2, 3}, []float64{-1, 0.5, 2, 8}
+	for _, f := range []BinaryFormat{
+		{},
+		{Order: binary.BigEndian, Float32: true},
+		{ColumnMajor: true},
+	} {
+		var buf bytes.Buffer
+		if err := WriteBinary(&buf, f, x, y); err != nil {
+			t.Fatal(err)
+		}
+		f.Columns = 2
+		cols, err := ReadBinary(bytes.NewReader(buf.Bytes()), f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range x {
+			if cols[0][i] != x[i] || cols[1][i] != y[i] {
+				t.Fatalf("%+v: row %d got %g, %g", f, i, cols[0][i], cols[1][i])
+			}
+		}
+		if f.ColumnMajor {
+			continue
+		}
+		br, err := NewBinaryReader(&buf, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := make([]float64, 2)
+		for i := range x {
+			if err := br.Read(row); err != nil || row[0] != x[i] || row[1] != y[i] {
+				t.Fatalf("%+v: row %d got %v, %v", f, i, row, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package decim
+
+import (
+	"errors"
+	"math"
+)
+
+// MinSegments returns the vertices of a connected piecewise linear
+// approximation of xyer with the fewest segments such that every input
+// point lies within tol of it in the vertical direction. Vertices are
+// placed at input x values, which are kept exactly, and their y values
+// are free.
+//
+// Lines through consecutive points are found by intersecting their
+// feasible regions in slope-intercept space (O'Rourke) starting from
+// each window of y values a vertex may take, as in Imai and Iri's
+// algorithm. The windows of each point are those reachable with the
+// fewest segments, which is enough to find an optimal approximation.
+// For segments spanning m points it takes O(n·m) time, and O(n) when
+// one segment suffices.
+//
+// Data x values must be strictly increasing.
+func MinSegments(xyer XYer, tol float64) (XYer, error) {
+	if xyer == nil {
+		panic("got nil xyer")
+	}
+	n := xyer.Len()
+	if n >= 2 && !(tol >= 0) {
+		return nil, errors.New("tolerance must be non-negative")
+	}
+	x, y, err := xyerSlices(xyer)
+	if err != nil {
+		return nil, err
+	}
+	if n < 2 {
+		return &sliceXYer{x: x, y: y}, nil
+	}
+	for i := 1; i < n; i++ {
+		if !(x[i] > x[i-1]) {
+			return nil, errors.New("x values not strictly increasing")
+		}
+	}
+
+	// count[j] is the fewest segments ending in a vertex at x[j] and
+	// windows[j] the y values such a vertex may take. Points are reached
+	// a layer at a time by sweeping from the windows of those with one
+	// segment less, stopping once the last point is reached.
+	count := make([]int, n)
+	windows := make([][]plaWindow, n)
+	windows[0] = []plaWindow{{lo: y[0] - tol, hi: y[0] + tol}}
+	for j := 1; j < n; j++ {
+		count[j] = -1
+	}
+	for first, last := 0, 0; count[n-1] < 0; {
+		reached := last
+		for i := first; i <= last; i++ {
+			for _, w := range windows[i] {
+				s := plaSweep{x: x, y: y, tol: tol, i: i, j: i, w: w}
+				for {
+					wj, ok := s.next()
+					if !ok {
+						break
+					}
+					switch c := count[i] + 1; count[s.j] {
+					case -1:
+						count[s.j], windows[s.j] = c, []plaWindow{wj}
+					case c:
+						windows[s.j] = addWindow(windows[s.j], wj)
+					}
+					if s.j > reached {
+						reached = s.j
+					}
+				}
+			}
+		}
+		first, last = last+1, reached
+	}
+
+	// Vertices are chosen going backwards, each on a line reaching the
+	// next one from a window of a point with one segment less.
+	v := &sliceXYer{x: make([]float64, count[n-1]+1), y: make([]float64, count[n-1]+1)}
+	j, yj := n-1, closestInWindows(windows[n-1], y[n-1])
+	for k := count[n-1]; k > 0; k-- {
+		v.x[k], v.y[k] = x[j], yj
+		i, yi := plaPrevious(x, y, tol, count, windows, j, yj)
+		j, yj = i, yi
+	}
+	v.x[0], v.y[0] = x[j], yj
+	return v, nil
+}
+
+// plaVertex is a line y = a*(x-x0) + b, where x0 is the segment start.
+type plaVertex struct {
+	a, b float64
+}
+
+func (v plaVertex) at(dx float64) float64 { return v.a*dx + v.b }
+
+// plaWindow is an interval of y values at an input x.
+type plaWindow struct {
+	lo, hi float64
+}
+
+// plaSweep follows the lines starting in window w at x[i] which pass
+// within tol of the points after i.
+type plaSweep struct {
+	x, y []float64
+	tol  float64
+	i    int
+	w    plaWindow
+	// j is the last point passed and poly the feasible lines,
+	// relative to x[i], passing within tol of points i+1 to j.
+	j    int
+	poly []plaVertex
+	// buf holds poly between clipping its two half-planes.
+	buf []plaVertex
+}
+
+// next passes the point after the last one and returns the window of y
+// values of the lines at its x. ok is false if no lines pass it.
+func (s *plaSweep) next() (w plaWindow, ok bool) {
+	s.j++
+	if s.j >= len(s.x) {
+		return w, false
+	}
+	dx := s.x[s.j] - s.x[s.i]
+	// Bands are widened by a rounding margin so lines which only
+	// just pass, such as those touching several bands, are kept.
+	margin := 1e-12 * (math.Abs(s.y[s.j]) + s.tol)
+	lo, hi := s.y[s.j]-s.tol-margin, s.y[s.j]+s.tol+margin
+	if s.poly == nil {
+		// Lines through the start window and the first point's tolerance band.
+		s.poly = []plaVertex{
+			{a: (lo - s.w.lo) / dx, b: s.w.lo},
+			{a: (hi - s.w.lo) / dx, b: s.w.lo},
+			{a: (hi - s.w.hi) / dx, b: s.w.hi},
+			{a: (lo - s.w.hi) / dx, b: s.w.hi},
+		}
+		return plaWindow{lo: lo, hi: hi}, true
+	}
+	s.buf = clipHalfPlane(s.buf[:0], s.poly, dx, hi, 1)
+	if s.poly = clipHalfPlane(s.poly[:0], s.buf, dx, lo, -1); len(s.poly) == 0 {
+		return w, false
+	}
+	lo, hi = polyRange(s.poly, dx)
+	return plaWindow{lo: lo, hi: hi}, true
+}
+
+// addWindow adds w to the sorted disjoint windows ws, merging those it overlaps.
+func addWindow(ws []plaWindow, w plaWindow) []plaWindow {
+	out := ws[:0:0]
+	for _, u := range ws {
+		switch {
+		case u.hi < w.lo || u.lo > w.hi:
+			out = append(out, u)
+		default:
+			w.lo, w.hi = math.Min(w.lo, u.lo), math.Max(w.hi, u.hi)
+		}
+	}
+	k := len(out)
+	for k > 0 && out[k-1].lo > w.lo {
+		k--
+	}
+	out = append(out, plaWindow{})
+	copy(out[k+1:], out[k:])
+	out[k] = w
+	return out
+}
+
+// closestInWindows returns the value in ws closest to y.
+func closestInWindows(ws []plaWindow, y float64) float64 {
+	best, bestDist := y, math.Inf(1)
+	for _, w := range ws {
+		c := math.Max(w.lo, math.Min(w.hi, y))
+		if d := math.Abs(c - y); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// plaPrevious returns a vertex at a point i before j, with count[j]-1
+// segments before it, such that a line from it passes within tol of the
+// points between and through (x[j], yj). If rounding leaves yj just out
+// of reach the closest line is used.
+func plaPrevious(x, y []float64, tol float64, count []int, windows [][]plaWindow, j int, yj float64) (i int, yi float64) {
+	bestDist := math.Inf(1)
+	for k := j - 1; k >= 0 && count[k] >= count[j]-1; k-- {
+		if count[k] != count[j]-1 {
+			continue
+		}
+		for _, w := range windows[k] {
+			s := plaSweep{x: x, y: y, tol: tol, i: k, j: k, w: w}
+			var wj plaWindow
+			ok := true
+			for s.j != j && ok {
+				wj, ok = s.next()
+			}
+			if !ok {
+				continue
+			}
+			d := math.Max(wj.lo-yj, yj-wj.hi)
+			if d < bestDist {
+				_, b := lineThrough(s.poly, x[j]-x[k], yj)
+				i, yi, bestDist = k, b, d
+			}
+			if d <= 0 {
+				return i, yi
+			}
+		}
+	}
+	return i, yi
+}
+
+// clipHalfPlane appends to dst the part of the convex polygon of lines
+// poly where sign*(y(dx)-bound) <= 0 and returns it.
+func clipHalfPlane(dst, poly []plaVertex, dx, bound, sign float64) []plaVertex {
+	for i := range poly {
+		cur, next := poly[i], poly[(i+1)%len(poly)]
+		fc, fn := sign*(cur.at(dx)-bound), sign*(next.at(dx)-bound)
+		if fc <= 0 {
+			dst = append(dst, cur)
+		}
+		if (fc < 0 && fn > 0) || (fc > 0 && fn < 0) {
+			t := fc / (fc - fn)
+			dst = append(dst, plaVertex{a: cur.a + t*(next.a-cur.a), b: cur.b + t*(next.b-cur.b)})
+		}
+	}
+	return dst
+}
+
+func polyRange(poly []plaVertex, dx float64) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range poly {
+		y := v.at(dx)
+		lo, hi = math.Min(lo, y), math.Max(hi, y)
+	}
+	return lo, hi
+}
+
+// lineThrough returns the line in poly passing through (dx, y) with
+// slope halfway between the extremes. If rounding leaves (dx, y) just
+// outside poly the closest vertex is returned.
+func lineThrough(poly []plaVertex, dx, y float64) (a, b float64) {
+	amin, amax := math.Inf(1), math.Inf(-1)
+	best, bestDist := poly[0], math.Inf(1)
+	for i := range poly {
+		cur, next := poly[i], poly[(i+1)%len(poly)]
+		fc, fn := cur.at(dx)-y, next.at(dx)-y
+		if d := math.Abs(fc); d < bestDist {
+			best, bestDist = cur, d
+		}
+		if fc == 0 {
+			amin, amax = math.Min(amin, cur.a), math.Max(amax, cur.a)
+		} else if (fc < 0 && fn > 0) || (fc > 0 && fn < 0) {
+			ai := cur.a + fc/(fc-fn)*(next.a-cur.a)
+			amin, amax = math.Min(amin, ai), math.Max(amax, ai)
+		}
+	}
+	if amin > amax {
+		return best.a, best.b
+	}
+	a = (amin + amax) / 2
+	return a, y - a*dx
+}
@@ -0,0 +1,53 @@
+package decim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMinSegments(t *testing.T) {
+	c := readTestCSV(t)
+	const tol = 1e-3
+	v, err := MinSegments(c, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSampler(c, tol)
+	s.Interp = true
+	if sv := s.XYer(); v.Len() > sv.Len() {
+		t.Errorf("MinSegments yielded %d points, more than Sampler's %d", v.Len(), sv.Len())
+	}
+	it, err := NewInterpolator(v, InterpLinear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < c.Len(); i++ {
+		x, y := c.XY(i)
+		if d := math.Abs(it.At(x) - y); d > tol*(1+1e-6) {
+			t.Fatalf("point %d (%g, %g) off by %g", i, x, y, d)
+		}
+	}
+}
+
+func TestMinSegmentsOptimal(t *testing.T) {
+	// Extending each segment as far as it goes needs 5 segments here.
+	x := []float64{0.24210465424535157, 1.1699829927457133, 2.403634041816302, 3.314532463874259, 4.270412933759632, 5.366604818303742, 6.0751823452145315, 7.402598067737821, 8.112019671084846}
+	y := []float64{-1, 0, 0, 0, 1.5, 1.5, 0.5, 0.5, -2.5}
+	const tol = 0.5
+	v, err := MinSegments(&sliceXYer{x: x, y: y}, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Len() != 5 {
+		t.Errorf("got %d vertices, want 5", v.Len())
+	}
+	it, err := NewInterpolator(v, InterpLinear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range x {
+		if d := math.Abs(it.At(x[i]) - y[i]); d > tol*(1+1e-6) {
+			t.Errorf("point %d (%g, %g) off by %g", i, x[i], y[i], d)
+		}
+	}
+}
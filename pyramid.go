@@ -0,0 +1,198 @@
+package decim
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Pyramid holds several decimations of the same data ordered from finest
+// to coarsest, so that zoomable plots can pick the level of detail
+// appropriate for the visible window. Data x values must be increasing.
+type Pyramid struct {
+	levels []pyramidLevel
+}
+
+type pyramidLevel struct {
+	tol float64
+	sliceXYer
+}
+
+// NewPyramid decimates xyer once per tolerance in tols, which are
+// sorted from finest (smallest) to coarsest. Each level is decimated
+// from the original data by a Sampler returning pivots so that every
+// point of xyer lies within the level's tolerance of it. The first and
+// last points are kept in every level. Tolerances must be positive and
+// data finite with increasing x values.
+func NewPyramid(xyer XYer, tols ...float64) (*Pyramid, error) {
+	if xyer == nil {
+		panic("got nil xyer")
+	}
+	if len(tols) == 0 {
+		panic("need at least one tolerance")
+	}
+	sorted := append([]float64(nil), tols...)
+	sort.Float64s(sorted)
+	if !(sorted[0] > 0) || math.IsInf(sorted[len(sorted)-1], 0) {
+		return nil, errors.New("tolerances must be positive and finite")
+	}
+	x, y, err := xyerSlices(xyer)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(x); i++ {
+		if x[i] < x[i-1] {
+			return nil, errors.New("x values not increasing")
+		}
+	}
+	data := &sliceXYer{x: x, y: y}
+	p := &Pyramid{levels: make([]pyramidLevel, len(sorted))}
+	for i, tol := range sorted {
+		p.levels[i].tol = tol
+		if data.Len() < 3 {
+			p.levels[i].sliceXYer = *data
+			continue
+		}
+		s := NewSampler(data, tol)
+		s.Pivots = true
+		p.levels[i].sliceXYer = *s.XYer().(*sliceXYer)
+	}
+	return p, nil
+}
+
+// Levels returns the number of levels in the pyramid.
+func (p *Pyramid) Levels() int { return len(p.levels) }
+
+// Level returns the tolerance and data of the i'th level. Level 0 is the finest.
+func (p *Pyramid) Level(i int) (tol float64, xy XYer) {
+	return p.levels[i].tol, &p.levels[i].sliceXYer
+}
+
+// Query returns the points of the finest level that has no more than
+// maxPoints points with x within [xmin, xmax], or the coarsest level if
+// none does. One or two points per horizontal pixel of the plot is a
+// good budget. The points just outside the window are included so that
+// lines reach the plot edges. The returned XYer shares memory with p.
+func (p *Pyramid) Query(xmin, xmax float64, maxPoints int) XYer {
+	var lo, hi int
+	var lvl *pyramidLevel
+	for i := range p.levels {
+		lvl = &p.levels[i]
+		lo = sort.SearchFloat64s(lvl.x, xmin)
+		hi = sort.Search(len(lvl.x), func(j int) bool { return lvl.x[j] > xmax })
+		if hi-lo <= maxPoints {
+			break
+		}
+	}
+	if lo > 0 {
+		lo--
+	}
+	if hi < len(lvl.x) {
+		hi++
+	}
+	return &sliceXYer{x: lvl.x[lo:hi], y: lvl.y[lo:hi]}
+}
+
+// pyramidMagic starts the binary representation of a Pyramid. The last byte is the version.
+const pyramidMagic = "DECIMPYR\x01"
+
+// WriteTo writes p to w in a compact binary format that may be read back
+// with ReadFrom. All numbers are written in little endian byte order.
+func (p *Pyramid) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	if _, err := io.WriteString(cw, pyramidMagic); err != nil {
+		return cw.n, err
+	}
+	if err := binary.Write(cw, binary.LittleEndian, uint32(len(p.levels))); err != nil {
+		return cw.n, err
+	}
+	for _, lvl := range p.levels {
+		for _, v := range []interface{}{lvl.tol, uint64(len(lvl.x)), lvl.x, lvl.y} {
+			if err := binary.Write(cw, binary.LittleEndian, v); err != nil {
+				return cw.n, err
+			}
+		}
+	}
+	return cw.n, nil
+}
+
+// ReadFrom replaces p's contents with a pyramid read from r as written by WriteTo.
+func (p *Pyramid) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countReader{r: r}
+	magic := make([]byte, len(pyramidMagic))
+	if _, err := io.ReadFull(cr, magic); err != nil {
+		return cr.n, err
+	}
+	if string(magic) != pyramidMagic {
+		return cr.n, errors.New("not a decim pyramid or unsupported version")
+	}
+	var nlevels uint32
+	if err := binary.Read(cr, binary.LittleEndian, &nlevels); err != nil {
+		return cr.n, err
+	}
+	var levels []pyramidLevel
+	for i := 0; i < int(nlevels); i++ {
+		var lvl pyramidLevel
+		var n uint64
+		if err := binary.Read(cr, binary.LittleEndian, &lvl.tol); err != nil {
+			return cr.n, err
+		}
+		if err := binary.Read(cr, binary.LittleEndian, &n); err != nil {
+			return cr.n, err
+		}
+		var err error
+		if lvl.x, err = readFloats(cr, n); err != nil {
+			return cr.n, fmt.Errorf("level %d: %w", i, err)
+		}
+		if lvl.y, err = readFloats(cr, n); err != nil {
+			return cr.n, fmt.Errorf("level %d: %w", i, err)
+		}
+		levels = append(levels, lvl)
+	}
+	p.levels = levels
+	return cr.n, nil
+}
+
+// readFloats reads n little endian float64s from r. Memory is allocated
+// as data arrives so corrupt lengths do not cause huge allocations.
+func readFloats(r io.Reader, n uint64) ([]float64, error) {
+	const chunk = 1 << 16
+	var f []float64
+	for uint64(len(f)) < n {
+		m := n - uint64(len(f))
+		if m > chunk {
+			m = chunk
+		}
+		start := len(f)
+		f = append(f, make([]float64, m)...)
+		if err := binary.Read(r, binary.LittleEndian, f[start:]); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
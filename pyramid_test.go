@@ -0,0 +1,98 @@
+package decim
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestPyramid(t *testing.T) {
+	c := readTestCSV(t)
+	p, err := NewPyramid(c, 1e-2, 1e-4, 1e-3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Levels() != 3 {
+		t.Fatalf("expected 3 levels, got %d", p.Levels())
+	}
+	x0, y0 := c.XY(0)
+	xn, yn := c.XY(c.Len() - 1)
+	prevLen := c.Len()
+	for i := 0; i < p.Levels(); i++ {
+		tol, lvl := p.Level(i)
+		if lvl.Len() >= prevLen {
+			t.Errorf("level %d not coarser than previous: %d >= %d", i, lvl.Len(), prevLen)
+		}
+		prevLen = lvl.Len()
+		if x, y := lvl.XY(0); x != x0 || y != y0 {
+			t.Errorf("level %d does not start at first point: (%g, %g)", i, x, y)
+		}
+		if x, y := lvl.XY(lvl.Len() - 1); x != xn || y != yn {
+			t.Errorf("level %d does not end at last point: (%g, %g)", i, x, y)
+		}
+		it, err := NewInterpolator(lvl, InterpLinear)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < c.Len(); j++ {
+			x, y := c.XY(j)
+			if d := math.Abs(it.At(x) - y); d > tol*(1+1e-6) {
+				t.Fatalf("level %d point %d (%g, %g) off by %g, more than tolerance %g", i, j, x, y, d, tol)
+			}
+		}
+	}
+	_, coarse := p.Level(2)
+	if got := p.Query(x0, xn, 10); got.Len() != coarse.Len() {
+		t.Errorf("small budget should yield coarsest level: got %d points", got.Len())
+	}
+	_, fine := p.Level(0)
+	if got := p.Query(x0, xn, c.Len()); got.Len() != fine.Len() {
+		t.Errorf("large budget should yield finest level: got %d points", got.Len())
+	}
+	mid := (x0 + xn) / 2
+	win := p.Query(mid, mid+(xn-x0)/100, 100)
+	first, _ := win.XY(0)
+	last, _ := win.XY(win.Len() - 1)
+	if win.Len() > 102 || first > mid || last < mid+(xn-x0)/100 {
+		t.Errorf("window query returned %d points spanning [%g, %g]", win.Len(), first, last)
+	}
+
+	var buf bytes.Buffer
+	n, err := p.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatal(n, err)
+	}
+	var got Pyramid
+	if _, err := got.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < p.Levels(); i++ {
+		tolWant, want := p.Level(i)
+		tolGot, lvl := got.Level(i)
+		if tolGot != tolWant || lvl.Len() != want.Len() {
+			t.Fatalf("level %d mismatch after read", i)
+		}
+		for j := 0; j < lvl.Len(); j++ {
+			if x, y := lvl.XY(j); x != want.(*sliceXYer).x[j] || y != want.(*sliceXYer).y[j] {
+				t.Fatalf("level %d point %d mismatch after read", i, j)
+			}
+		}
+	}
+}
+
+func TestPyramidBadData(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		x, y []float64
+		tol  float64
+	}{
+		{name: "NaN", x: []float64{0, 1, 2, 3}, y: []float64{0, math.NaN(), 1, 2}, tol: 0.1},
+		{name: "infinity", x: []float64{0, 1, math.Inf(1), 3}, y: []float64{0, 1, 1, 2}, tol: 0.1},
+		{name: "decreasing x", x: []float64{0, 2, 1, 3}, y: []float64{0, 1, 1, 2}, tol: 0.1},
+		{name: "zero tolerance", x: []float64{0, 1, 2, 3}, y: []float64{0, 1, 1, 2}, tol: 0},
+	} {
+		if _, err := NewPyramid(&sliceXYer{x: test.x, y: test.y}, test.tol); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
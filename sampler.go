@@ -24,6 +24,14 @@ type Sampler struct {
 	// the angle limit range. Setting interp
 	// means y values will not coincide with input data.
 	Interp bool
+	// Pivots makes Next return the first point, the pivots where
+	// the line changes direction and the last point instead of the
+	// points after each pivot, so that every input point lies within
+	// tol of the result interpolated linearly.
+	Pivots bool
+	// started and ended are set once the first and
+	// last points are returned with Pivots.
+	started, ended bool
 }
 
 func NewSampler(xyer XYer, tol float64) *Sampler {
@@ -52,14 +60,19 @@ func (s *Sampler) Reset() {
 	s.yPrev = y
 	s.xPivot = x
 	s.yPivot = y
+	s.started, s.ended = false, false
 }
 
 func (s *Sampler) Next() (x, y float64, err error) {
 	n := s.xyer.Len()
+	if s.Pivots && !s.started {
+		s.started = true
+		return s.xPivot, s.yPivot, nil
+	}
 	for s.idx < n {
 		x, y = s.xyer.XY(s.idx)
 		s.idx++
-		if s.idx == n {
+		if s.idx == n && !s.Pivots {
 			// Return last data without modification.
 			return x, y, nil
 		}
@@ -76,6 +89,12 @@ func (s *Sampler) Next() (x, y float64, err error) {
 				s.yPivot = s.yPrev
 			}
 			s.xPivot, s.xPrev, s.yPrev = s.xPrev, x, y
+			if s.Pivots {
+				// Lines from the new pivot must pass within tol of this point too.
+				dx, dy = x-s.xPivot, y-s.yPivot
+				s.angleMin, s.angleMax = math.Atan2(dy-s.tol, dx), math.Atan2(dy+s.tol, dx)
+				return s.xPivot, s.yPivot, nil
+			}
 			s.setStartAngleLims()
 			return x, y, nil
 		}
@@ -86,6 +105,10 @@ func (s *Sampler) Next() (x, y float64, err error) {
 		s.xPrev = x
 		s.yPrev = y
 	}
+	if s.Pivots && !s.ended {
+		s.ended = true
+		return s.xPrev, s.yPrev, nil
+	}
 	return x, y, io.EOF
 }
 
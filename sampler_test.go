@@ -1,26 +1,18 @@
 package decim
 
 import (
-	"encoding/csv"
 	"errors"
 	"io"
 	"os"
-	"strconv"
 	"testing"
 )
 
 func TestSampler(t *testing.T) {
-	fp, _ := os.Open("testdata/ch4.csv")
-	rd := csv.NewReader(fp)
-	rd.Read() // read header
-	rec, err := rd.ReadAll()
-	if err != nil {
-		t.Fatal(err)
-	}
-	c := &CSVXYer{xField: 0, yField: 1, records: rec}
+	c := readTestCSV(t)
 	s := NewSampler(c, 1)
 	var xs, ys []float64
 	var x, y float64
+	var err error
 	for ; err == nil; x, y, err = s.Next() {
 		xs = append(xs, x)
 		ys = append(ys, y)
@@ -33,22 +25,16 @@ func TestSampler(t *testing.T) {
 	}
 }
 
-type CSVXYer struct {
-	xField, yField int
-	records        [][]string
-}
-
-func (c *CSVXYer) XY(i int) (x, y float64) {
-	rec := c.records[i]
-	x, err := strconv.ParseFloat(rec[c.xField], 64)
+func readTestCSV(t *testing.T) XYer {
+	t.Helper()
+	fp, err := os.Open("testdata/ch4.csv")
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
-	y, err = strconv.ParseFloat(rec[c.yField], 64)
+	defer fp.Close()
+	c, err := ReadCSV(fp, ',', "1", "2")
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
-	return x, y
+	return c
 }
-
-func (c *CSVXYer) Len() int { return len(c.records) }
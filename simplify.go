@@ -0,0 +1,260 @@
+package decim
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+)
+
+// RDP returns the points of xyer kept by the Ramer–Douglas–Peucker
+// algorithm. Distances are measured in the vertical direction, from the
+// chord between kept points at the x value of each point, so that every
+// input point lies within tol of the result interpolated linearly.
+//
+// Data x values must be strictly increasing.
+func RDP(xyer XYer, tol float64) (XYer, error) {
+	if xyer == nil {
+		panic("got nil xyer")
+	}
+	if !(tol >= 0) {
+		return nil, errors.New("tolerance must be non-negative")
+	}
+	x, y, err := xyerSlices(xyer)
+	if err != nil {
+		return nil, err
+	}
+	keep := rdp(len(x), tol, func(i, first, last int) float64 {
+		slope := (y[last] - y[first]) / (x[last] - x[first])
+		return math.Abs(y[i] - y[first] - slope*(x[i]-x[first]))
+	})
+	return keptPoints(x, y, keep), nil
+}
+
+// rdp returns which of n points the Ramer–Douglas–Peucker algorithm keeps
+// given dist, the distance of point i to the line between points first
+// and last.
+func rdp(n int, tol float64, dist func(i, first, last int) float64) []bool {
+	keep := make([]bool, n)
+	if n < 3 {
+		for i := range keep {
+			keep[i] = true
+		}
+		return keep
+	}
+	keep[0], keep[n-1] = true, true
+	// Ranges are split with a stack instead of recursion which
+	// could be as deep as the number of points.
+	stack := [][2]int{{0, n - 1}}
+	for len(stack) > 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+		maxDist, split := tol, -1
+		for i := first + 1; i < last; i++ {
+			if d := dist(i, first, last); d > maxDist {
+				maxDist, split = d, i
+			}
+		}
+		if split >= 0 {
+			keep[split] = true
+			stack = append(stack, [2]int{first, split}, [2]int{split, last})
+		}
+	}
+	return keep
+}
+
+// Visvalingam returns n points of xyer chosen by the Visvalingam–Whyatt
+// algorithm, which repeatedly removes the point forming the triangle of
+// least area with its neighbours. The area of a point's triangle is not
+// allowed to fall below that of points removed before it so that removal
+// order follows the significance of points. The first and last points are
+// kept.
+//
+// Data x values must be strictly increasing.
+func Visvalingam(xyer XYer, n int) (XYer, error) {
+	if xyer == nil {
+		panic("got nil xyer")
+	}
+	if n < 2 {
+		return nil, errors.New("need at least 2 points")
+	}
+	x, y, err := xyerSlices(xyer)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) <= n {
+		return &sliceXYer{x: x, y: y}, nil
+	}
+	prev, next := make([]int, len(x)), make([]int, len(x))
+	h := &areaHeap{pos: make([]int, len(x))}
+	area := func(i int) float64 {
+		a, c := prev[i], next[i]
+		return math.Abs((x[i]-x[a])*(y[c]-y[a])-(x[c]-x[a])*(y[i]-y[a])) / 2
+	}
+	for i := range x {
+		prev[i], next[i] = i-1, i+1
+	}
+	for i := 1; i < len(x)-1; i++ {
+		h.items = append(h.items, areaItem{i: i, area: area(i)})
+		h.pos[i] = len(h.items) - 1
+	}
+	heap.Init(h)
+	keep := make([]bool, len(x))
+	for i := range keep {
+		keep[i] = true
+	}
+	for left := len(x); left > n; left-- {
+		it := heap.Pop(h).(areaItem)
+		keep[it.i] = false
+		a, c := prev[it.i], next[it.i]
+		next[a], prev[c] = c, a
+		for _, j := range []int{a, c} {
+			if j == 0 || j == len(x)-1 {
+				continue
+			}
+			h.items[h.pos[j]].area = math.Max(area(j), it.area)
+			heap.Fix(h, h.pos[j])
+		}
+	}
+	return keptPoints(x, y, keep), nil
+}
+
+// LTTB returns n points of xyer chosen by the Largest-Triangle-Three-Buckets
+// algorithm (Steinarsson). Points between the first and last, which are
+// kept, are split into n-2 buckets and the point of each bucket forming
+// the largest triangle with the point kept from the bucket before and the
+// average of the bucket after is kept.
+//
+// Data x values must be increasing.
+func LTTB(xyer XYer, n int) (XYer, error) {
+	if xyer == nil {
+		panic("got nil xyer")
+	}
+	if n < 3 {
+		return nil, errors.New("need at least 3 points")
+	}
+	x, y, err := xyerSlices(xyer)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) <= n {
+		return &sliceXYer{x: x, y: y}, nil
+	}
+	keep := make([]bool, len(x))
+	keep[0], keep[len(x)-1] = true, true
+	every := float64(len(x)-2) / float64(n-2)
+	bucket := func(b int) int { return int(math.Floor(float64(b)*every)) + 1 }
+	a := 0
+	for b := 0; b < n-2; b++ {
+		// The last bucket is followed by the last point alone.
+		next, nextEnd := bucket(b+1), bucket(b+2)
+		if nextEnd > len(x) {
+			nextEnd = len(x)
+		}
+		var avgx, avgy float64
+		for i := next; i < nextEnd; i++ {
+			avgx, avgy = avgx+x[i], avgy+y[i]
+		}
+		avgx, avgy = avgx/float64(nextEnd-next), avgy/float64(nextEnd-next)
+		maxArea, kept := -1.0, bucket(b)
+		for i := bucket(b); i < next; i++ {
+			area := math.Abs((x[a]-avgx)*(y[i]-y[a]) - (x[a]-x[i])*(avgy-y[a]))
+			if area > maxArea {
+				maxArea, kept = area, i
+			}
+		}
+		keep[kept], a = true, kept
+	}
+	return keptPoints(x, y, keep), nil
+}
+
+// M4 returns the first, last, minimum and maximum points of xyer within
+// each of buckets intervals of equal width spanning its x range, in their
+// original order. When buckets is the width in pixels of a plot the
+// result is drawn as the original data would be (Jugel et al.).
+//
+// Data x values must be increasing.
+func M4(xyer XYer, buckets int) (XYer, error) {
+	if xyer == nil {
+		panic("got nil xyer")
+	}
+	if buckets < 1 {
+		return nil, errors.New("need at least one bucket")
+	}
+	x, y, err := xyerSlices(xyer)
+	if err != nil {
+		return nil, err
+	}
+	n := len(x)
+	if n < 3 {
+		return &sliceXYer{x: x, y: y}, nil
+	}
+	keep := make([]bool, n)
+	width := (x[n-1] - x[0]) / float64(buckets)
+	for first := 0; first < n; {
+		b := math.Floor((x[first] - x[0]) / width)
+		last, min, max := first, first, first
+		for last+1 < n && (math.Floor((x[last+1]-x[0])/width) == b || b >= float64(buckets-1)) {
+			last++
+			if y[last] < y[min] {
+				min = last
+			}
+			if y[last] > y[max] {
+				max = last
+			}
+		}
+		keep[first], keep[last], keep[min], keep[max] = true, true, true, true
+		first = last + 1
+	}
+	return keptPoints(x, y, keep), nil
+}
+
+// xyerSlices copies the data of xyer, which must be finite.
+func xyerSlices(xyer XYer) (x, y []float64, err error) {
+	n := xyer.Len()
+	x, y = make([]float64, n), make([]float64, n)
+	for i := range x {
+		x[i], y[i] = xyer.XY(i)
+		if math.IsNaN(x[i]) || math.IsInf(x[i], 0) || math.IsNaN(y[i]) || math.IsInf(y[i], 0) {
+			return nil, nil, errors.New("got infinity or NaN")
+		}
+	}
+	return x, y, nil
+}
+
+func keptPoints(x, y []float64, keep []bool) *sliceXYer {
+	v := &sliceXYer{}
+	for i, k := range keep {
+		if k {
+			v.x, v.y = append(v.x, x[i]), append(v.y, y[i])
+		}
+	}
+	return v
+}
+
+// areaHeap is a min-heap of point triangle areas which keeps
+// track of the position of each point for updates.
+type areaHeap struct {
+	items []areaItem
+	pos   []int
+}
+
+type areaItem struct {
+	i    int
+	area float64
+}
+
+func (h *areaHeap) Len() int           { return len(h.items) }
+func (h *areaHeap) Less(i, j int) bool { return h.items[i].area < h.items[j].area }
+func (h *areaHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.pos[h.items[i].i], h.pos[h.items[j].i] = i, j
+}
+func (h *areaHeap) Push(v interface{}) {
+	h.pos[v.(areaItem).i] = len(h.items)
+	h.items = append(h.items, v.(areaItem))
+}
+func (h *areaHeap) Pop() interface{} {
+	it := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return it
+}
@@ -0,0 +1,110 @@
+package decim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRDP(t *testing.T) {
+	c := readTestCSV(t)
+	const tol = 1e-3
+	v, err := RDP(c, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Len() >= c.Len() {
+		t.Errorf("RDP kept all %d points", c.Len())
+	}
+	it, err := NewInterpolator(v, InterpLinear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < c.Len(); i++ {
+		x, y := c.XY(i)
+		if d := math.Abs(it.At(x) - y); d > tol*(1+1e-6) {
+			t.Fatalf("point %d (%g, %g) off by %g", i, x, y, d)
+		}
+	}
+}
+
+func TestVisvalingam(t *testing.T) {
+	c := readTestCSV(t)
+	const n = 50
+	v, err := Visvalingam(c, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Len() != n {
+		t.Fatalf("got %d points, want %d", v.Len(), n)
+	}
+	testEndpoints(t, c, v)
+	if _, err := Visvalingam(c, 1); err == nil {
+		t.Error("expected error for less than 2 points")
+	}
+}
+
+func TestLTTB(t *testing.T) {
+	c := readTestCSV(t)
+	const n = 50
+	v, err := LTTB(c, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Len() != n {
+		t.Fatalf("got %d points, want %d", v.Len(), n)
+	}
+	testEndpoints(t, c, v)
+	// The point kept from each bucket is one of the input points.
+	j := 0
+	for i := 0; i < v.Len(); i++ {
+		vx, vy := v.XY(i)
+		for ; j < c.Len(); j++ {
+			if x, y := c.XY(j); x == vx && y == vy {
+				break
+			}
+		}
+		if j == c.Len() {
+			t.Fatalf("point %d (%g, %g) not in input order", i, vx, vy)
+		}
+	}
+	if _, err := LTTB(c, 2); err == nil {
+		t.Error("expected error for less than 3 points")
+	}
+}
+
+func TestM4(t *testing.T) {
+	c := readTestCSV(t)
+	const buckets = 20
+	v, err := M4(c, buckets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Len() > 4*buckets {
+		t.Errorf("got %d points, more than 4 per bucket", v.Len())
+	}
+	testEndpoints(t, c, v)
+	ymin, ymax := math.Inf(1), math.Inf(-1)
+	for i := 0; i < c.Len(); i++ {
+		_, y := c.XY(i)
+		ymin, ymax = math.Min(ymin, y), math.Max(ymax, y)
+	}
+	vmin, vmax := math.Inf(1), math.Inf(-1)
+	for i := 0; i < v.Len(); i++ {
+		_, y := v.XY(i)
+		vmin, vmax = math.Min(vmin, y), math.Max(vmax, y)
+	}
+	if vmin != ymin || vmax != ymax {
+		t.Errorf("got y range [%g, %g], want [%g, %g]", vmin, vmax, ymin, ymax)
+	}
+}
+
+func testEndpoints(t *testing.T, original, v XYer) {
+	t.Helper()
+	x0, y0 := original.XY(0)
+	x1, y1 := original.XY(original.Len() - 1)
+	vx0, vy0 := v.XY(0)
+	vx1, vy1 := v.XY(v.Len() - 1)
+	if vx0 != x0 || vy0 != y0 || vx1 != x1 || vy1 != y1 {
+		t.Errorf("endpoints not kept: got (%g, %g) and (%g, %g)", vx0, vy0, vx1, vy1)
+	}
+}
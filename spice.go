@@ -0,0 +1,348 @@
+package decim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SpiceRaw holds the waveforms of a SPICE .raw file as written by
+// LTspice and ngspice, in binary or ASCII form, real or complex.
+// Only the first plot of files holding several is read.
+type SpiceRaw struct {
+	Title, Date, Plotname, Command string
+	// Flags such as "real", "complex", "forward", "log" or "stepped".
+	Flags []string
+	Vars  []SpiceVar
+	// Points is the total number of points of all steps.
+	Points int
+
+	re, im [][]float64
+	// steps holds the first point of each step.
+	steps []int
+}
+
+// SpiceVar describes a variable of a SPICE .raw file.
+// The first variable is the sweep variable (time, frequency...).
+type SpiceVar struct {
+	Name, Type string
+}
+
+// ReadSpiceRaw reads a SPICE .raw file. LTspice headers are encoded
+// in UTF-16 while ngspice ones are ASCII, both are detected.
+func ReadSpiceRaw(r io.Reader) (*SpiceRaw, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	peek, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	rr := &spiceReader{r: br, utf16: peek[1] == 0}
+	s := &SpiceRaw{}
+	nvars := -1
+	var binaryData bool
+header:
+	for {
+		line, err := rr.readLine()
+		if err != nil {
+			return nil, fmt.Errorf("reading .raw header: %w", err)
+		}
+		key, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			key, value = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		switch strings.ToLower(key) {
+		case "title":
+			s.Title = value
+		case "date":
+			s.Date = value
+		case "plotname":
+			s.Plotname = value
+		case "command":
+			s.Command = value
+		case "flags":
+			s.Flags = strings.Fields(strings.ToLower(value))
+		case "no. variables":
+			nvars, err = strconv.Atoi(value)
+		case "no. points":
+			s.Points, err = strconv.Atoi(value)
+		case "variables":
+			if nvars < 0 {
+				return nil, errors.New("variables listed before their number")
+			}
+			s.Vars = make([]SpiceVar, nvars)
+			for i := range s.Vars {
+				line, err := rr.readLine()
+				if err != nil {
+					return nil, err
+				}
+				f := strings.Fields(line)
+				if len(f) < 3 {
+					return nil, fmt.Errorf("bad variable line %q", line)
+				}
+				s.Vars[i] = SpiceVar{Name: f[1], Type: f[2]}
+			}
+		case "binary":
+			binaryData = true
+			break header
+		case "values":
+			break header
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad %s header value %q", key, value)
+		}
+	}
+	if len(s.Vars) == 0 || s.Points < 0 {
+		return nil, errors.New("missing variables or points in .raw header")
+	}
+	s.re = make([][]float64, len(s.Vars))
+	if s.Complex() {
+		s.im = make([][]float64, len(s.Vars))
+	}
+	if binaryData {
+		err = s.readBinary(br, rr.utf16)
+	} else {
+		err = s.readASCII(rr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.findSteps()
+	return s, nil
+}
+
+func (s *SpiceRaw) hasFlag(flag string) bool {
+	for _, f := range s.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Complex reports whether the file holds complex values, as AC analyses do.
+func (s *SpiceRaw) Complex() bool { return s.hasFlag("complex") }
+
+func (s *SpiceRaw) readBinary(r io.Reader, ltspice bool) error {
+	n, nvars := s.Points, len(s.Vars)
+	// ngspice writes all values as double. LTspice writes the sweep
+	// variable as double and the rest as float unless "double" is flagged.
+	sizes := make([]int, nvars)
+	for i := range sizes {
+		switch {
+		case s.Complex():
+			sizes[i] = 16
+		case ltspice && !s.hasFlag("double") && (i > 0 || strings.EqualFold(s.Plotname, "Operating Point")):
+			sizes[i] = 4
+		default:
+			sizes[i] = 8
+		}
+	}
+	var buf [16]byte
+	readValue := func(v int) error {
+		b := buf[:sizes[v]]
+		if _, err := io.ReadFull(r, b); err != nil {
+			return err
+		}
+		switch sizes[v] {
+		case 4:
+			s.re[v] = append(s.re[v], float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+		case 8:
+			s.re[v] = append(s.re[v], math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		case 16:
+			s.re[v] = append(s.re[v], math.Float64frombits(binary.LittleEndian.Uint64(b)))
+			s.im[v] = append(s.im[v], math.Float64frombits(binary.LittleEndian.Uint64(b[8:])))
+		}
+		return nil
+	}
+	var err error
+	if s.hasFlag("fastaccess") {
+		// LTspice stores each variable's values contiguously.
+		for v := 0; v < nvars && err == nil; v++ {
+			for i := 0; i < n && err == nil; i++ {
+				err = readValue(v)
+			}
+		}
+	} else {
+		for i := 0; i < n && err == nil; i++ {
+			for v := 0; v < nvars && err == nil; v++ {
+				err = readValue(v)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("reading .raw binary data: %w", err)
+	}
+	if ltspice && !s.Complex() {
+		// LTspice flags compressed points with a negative sign in time.
+		for i, t := range s.re[0] {
+			s.re[0][i] = math.Abs(t)
+		}
+	}
+	return nil
+}
+
+func (s *SpiceRaw) readASCII(rr *spiceReader) error {
+	for i := 0; i < s.Points; i++ {
+		if _, err := rr.readWord(); err != nil { // point index
+			return fmt.Errorf("reading .raw point %d: %w", i, err)
+		}
+		for v := range s.Vars {
+			word, err := rr.readWord()
+			if err != nil {
+				return fmt.Errorf("reading .raw point %d: %w", i, err)
+			}
+			re, im := word, ""
+			if c := strings.IndexByte(word, ','); c >= 0 {
+				re, im = word[:c], word[c+1:]
+			}
+			fre, err := strconv.ParseFloat(re, 64)
+			if err != nil {
+				return fmt.Errorf("point %d of %s: %w", i, s.Vars[v].Name, err)
+			}
+			s.re[v] = append(s.re[v], fre)
+			if s.im != nil {
+				fim, err := strconv.ParseFloat(im, 64)
+				if err != nil {
+					return fmt.Errorf("point %d of %s: %w", i, s.Vars[v].Name, err)
+				}
+				s.im[v] = append(s.im[v], fim)
+			}
+		}
+	}
+	return nil
+}
+
+// findSteps splits stepped runs where the sweep variable returns to its first value.
+func (s *SpiceRaw) findSteps() {
+	s.steps = []int{0}
+	if !s.hasFlag("stepped") || s.Points == 0 {
+		return
+	}
+	first := s.re[0][0]
+	for i := 1; i < s.Points; i++ {
+		if s.re[0][i] == first {
+			s.steps = append(s.steps, i)
+		}
+	}
+}
+
+// Steps returns the number of stepped runs in the file, which is 1 for unstepped runs.
+func (s *SpiceRaw) Steps() int { return len(s.steps) }
+
+// VarIndex returns the index of the variable with name, which
+// is compared case insensitively, or -1 if not found.
+func (s *SpiceRaw) VarIndex(name string) int {
+	for i, v := range s.Vars {
+		if strings.EqualFold(v.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *SpiceRaw) stepRange(step int) (start, end int) {
+	end = s.Points
+	if step+1 < len(s.steps) {
+		end = s.steps[step+1]
+	}
+	return s.steps[step], end
+}
+
+// Real returns the real part of variable v's values during step, starting at 0.
+// The returned slice shares memory with s.
+func (s *SpiceRaw) Real(v, step int) []float64 {
+	start, end := s.stepRange(step)
+	return s.re[v][start:end]
+}
+
+// Imag returns the imaginary part of variable v's values during step.
+// It returns nil for real data.
+func (s *SpiceRaw) Imag(v, step int) []float64 {
+	if s.im == nil {
+		return nil
+	}
+	start, end := s.stepRange(step)
+	return s.im[v][start:end]
+}
+
+// Trace returns the values of the variable called name during step against
+// the sweep variable. For complex data the magnitude is returned as y
+// and the real part of the sweep variable as x.
+func (s *SpiceRaw) Trace(name string, step int) (XYer, error) {
+	v := s.VarIndex(name)
+	if v < 0 {
+		return nil, fmt.Errorf("variable %q not in .raw file", name)
+	}
+	if step < 0 || step >= len(s.steps) {
+		return nil, fmt.Errorf("step %d out of range. file has %d steps", step, len(s.steps))
+	}
+	xy := &sliceXYer{x: s.Real(0, step), y: s.Real(v, step)}
+	if s.im != nil {
+		re, im := xy.y, s.Imag(v, step)
+		xy.y = make([]float64, len(im))
+		for i := range im {
+			xy.y[i] = cmplx.Abs(complex(re[i], im[i]))
+		}
+	}
+	return xy, nil
+}
+
+// spiceReader reads text which may be encoded in UTF-16 little endian.
+type spiceReader struct {
+	r     *bufio.Reader
+	utf16 bool
+}
+
+func (s *spiceReader) readRune() (rune, error) {
+	if !s.utf16 {
+		b, err := s.r.ReadByte()
+		return rune(b), err
+	}
+	var b [2]byte
+	if _, err := io.ReadFull(s.r, b[:]); err != nil {
+		return 0, err
+	}
+	return rune(binary.LittleEndian.Uint16(b[:])), nil
+}
+
+func (s *spiceReader) readLine() (string, error) {
+	var sb strings.Builder
+	for {
+		r, err := s.readRune()
+		if err != nil {
+			return "", err
+		}
+		if r == '\n' {
+			return strings.TrimRight(sb.String(), "\r"), nil
+		}
+		sb.WriteRune(r)
+	}
+}
+
+// readWord reads the next whitespace separated word.
+func (s *spiceReader) readWord() (string, error) {
+	var sb strings.Builder
+	for {
+		r, err := s.readRune()
+		if err != nil {
+			if sb.Len() > 0 && errors.Is(err, io.EOF) {
+				return sb.String(), nil
+			}
+			return "", err
+		}
+		if unicode.IsSpace(r) {
+			if sb.Len() > 0 {
+				return sb.String(), nil
+			}
+			continue
+		}
+		sb.WriteRune(r)
+	}
+}
@@ -0,0 +1,107 @@
+package decim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestSpiceRawASCII(t *testing.T) {
+	const raw = `Title: * test circuit
+Date: Thu Jan  1 00:00:00 2021
+Plotname: Transient Analysis
+Flags: real
+No. Variables: 3
+No. Points: 3
+Variables:
+	0	time	time
+	1	v(out)	voltage
+	2	i(r1)	current
+Values:
+ 0	0.000000000000000e+00
+	1.0
+	-1e-3
+ 1	1e-6
+	2.0
+	-2e-3
+ 2	2e-6
+	3.0
+	-3e-3
+`
+	s, err := ReadSpiceRaw(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Plotname != "Transient Analysis" || len(s.Vars) != 3 || s.Points != 3 || s.Complex() {
+		t.Fatalf("bad header: %+v", s)
+	}
+	tr, err := s.Trace("V(out)", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, y := tr.XY(2); tr.Len() != 3 || x != 2e-6 || y != 3 {
+		t.Errorf("bad trace point (%g, %g)", x, y)
+	}
+}
+
+// ltspiceRaw builds an LTspice style file with UTF-16 header,
+// double precision time and single precision values.
+func ltspiceRaw(header string, time []float64, values [][]float32) []byte {
+	var buf bytes.Buffer
+	for _, r := range utf16.Encode([]rune(header)) {
+		binary.Write(&buf, binary.LittleEndian, r)
+	}
+	for i, tm := range time {
+		binary.Write(&buf, binary.LittleEndian, tm)
+		for _, v := range values {
+			binary.Write(&buf, binary.LittleEndian, v[i])
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestSpiceRawLTspice(t *testing.T) {
+	header := "Title: * test\nDate: today\nPlotname: Transient Analysis\nFlags: real forward stepped\n" +
+		"No. Variables: 2\nNo. Points: 5\nOffset: 0.0\nCommand: Linear Technology Corporation LTspice XVII\n" +
+		"Variables:\n\t0\ttime\ttime\n\t1\tV(out)\tvoltage\nBinary:\n"
+	// Two steps, second one with a negative (compressed) time point.
+	data := ltspiceRaw(header, []float64{0, 1, 2, 0, -1}, [][]float32{{1, 2, 3, 10, 20}})
+	s, err := ReadSpiceRaw(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Steps() != 2 {
+		t.Fatalf("expected 2 steps, got %d", s.Steps())
+	}
+	tr, err := s.Trace("v(out)", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, y := tr.XY(1); tr.Len() != 2 || x != 1 || y != 20 {
+		t.Errorf("bad step 2 point (%g, %g)", x, y)
+	}
+}
+
+func TestSpiceRawComplex(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("Title: ac\nPlotname: AC Analysis\nFlags: complex\nNo. Variables: 2\nNo. Points: 2\n" +
+		"Variables:\n\t0\tfrequency\tfrequency\n\t1\tv(out)\tvoltage\nBinary:\n")
+	binary.Write(&buf, binary.LittleEndian, []float64{1, 0, 3, 4, 10, 0, 0, -2})
+	s, err := ReadSpiceRaw(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := s.Trace("v(out)", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, y := tr.XY(0); x != 1 || y != 5 {
+		t.Errorf("bad magnitude point (%g, %g)", x, y)
+	}
+	if im := s.Imag(1, 0); len(im) != 2 || im[1] != -2 || math.IsNaN(im[0]) {
+		t.Errorf("bad imaginary part %v", im)
+	}
+}
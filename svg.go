@@ -0,0 +1,224 @@
+package decim
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// SVGColors are the default stroke colors of series drawn by SVG.
+var SVGColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"}
+
+// Margins around the plot area when axes are drawn, in pixels.
+const (
+	svgMarginLeft   = 64
+	svgMarginRight  = 16
+	svgMarginTop    = 16
+	svgMarginBottom = 32
+)
+
+// SVG renders series as SVG path elements. Data is mapped from the
+// Viewport's x and y range to a plot area of Width×Height pixels,
+// which is the SVG viewBox unless Axes are drawn around it. Series are
+// usually decimated beforehand, e.g. with Viewport.Decimate.
+type SVG struct {
+	Viewport
+	// Tolerance is the y tolerance series were decimated with in data
+	// units. It sets the precision of coordinates so that rounding does not
+	// add visible error. Zero means half a pixel, as Viewport.Decimate uses.
+	Tolerance float64
+	// Axes draws a frame with tick labels around the plot area.
+	Axes bool
+	// Colors of series strokes, used in turn. Defaults to SVGColors.
+	Colors []string
+	// StrokeWidth of series in pixels. Zero means 1.
+	StrokeWidth float64
+}
+
+// Write writes an SVG document with a path per series to w. NaN values
+// break paths and points outside the viewport are clipped.
+func (s *SVG) Write(w io.Writer, series ...XYer) error {
+	return s.write(w, len(series), func(p *svgPath, i int) {
+		// Coordinates following a move command are implicit line commands.
+		cmd := "M"
+		xy := series[i]
+		for j := 0; j < xy.Len(); j++ {
+			x, y := xy.XY(j)
+			if math.IsNaN(x) || math.IsNaN(y) {
+				cmd = "M"
+				continue
+			}
+			p.command(cmd, x, y)
+			cmd = ""
+		}
+	})
+}
+
+// WriteCurves writes an SVG document with a path of cubic Bézier curves,
+// such as those returned by FitBezier, per series to w. Curves are in data
+// coordinates like the series of Write.
+func (s *SVG) WriteCurves(w io.Writer, series ...[]Bezier) error {
+	return s.write(w, len(series), func(p *svgPath, i int) {
+		curves := series[i]
+		for j, c := range curves {
+			if j == 0 || curves[j-1].point(3) != c.point(0) {
+				p.command("M", c.X[0], c.Y[0])
+			}
+			p.command("C", c.X[1], c.Y[1], c.X[2], c.Y[2], c.X[3], c.Y[3])
+		}
+	})
+}
+
+// write writes an SVG document with n path elements
+// whose data is written by path.
+func (s *SVG) write(w io.Writer, n int, path func(p *svgPath, i int)) error {
+	if !(s.Width > 0 && s.Height > 0) {
+		return errors.New("SVG needs a positive width and height")
+	}
+	if math.IsInf(s.XMax-s.XMin, 0) || math.IsInf(s.YMax-s.YMin, 0) || math.IsNaN(s.XMax-s.XMin) || math.IsNaN(s.YMax-s.YMin) {
+		return errors.New("SVG viewport must have a finite data range")
+	}
+	colors, stroke := s.Colors, s.StrokeWidth
+	if len(colors) == 0 {
+		colors = SVGColors
+	}
+	if stroke == 0 {
+		stroke = 1
+	}
+	var left, top, width, height float64 = 0, 0, s.Width, s.Height
+	if s.Axes {
+		left, top = svgMarginLeft, svgMarginTop
+		width += svgMarginLeft + svgMarginRight
+		height += svgMarginTop + svgMarginBottom
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n", width, height, width, height)
+	fmt.Fprintf(bw, `<clipPath id="plot"><rect x="%g" y="%g" width="%g" height="%g"/></clipPath>`+"\n", left, top, s.Width, s.Height)
+	if s.Axes {
+		s.writeAxes(bw, left, top)
+	}
+	prec := s.precision()
+	var buf []byte
+	for i := 0; i < n; i++ {
+		buf = append(buf[:0], `<path fill="none" clip-path="url(#plot)" stroke="`...)
+		buf = append(buf, colors[i%len(colors)]...)
+		buf = append(buf, `" stroke-width="`...)
+		buf = strconv.AppendFloat(buf, stroke, 'g', -1, 64)
+		buf = append(buf, `" d="`...)
+		bw.Write(buf)
+		path(&svgPath{svg: s, w: bw, left: left, top: top, prec: prec}, i)
+		bw.WriteString("\"/>\n")
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// precision returns the decimals of pixel coordinates so
+// that rounding error is at most half the tolerance.
+func (s *SVG) precision() int {
+	tol := s.Tolerance
+	if tol <= 0 {
+		_, tol = s.Viewport.Tolerance()
+	}
+	tolPx := tol * s.Height / (s.YMax - s.YMin)
+	prec := int(math.Ceil(-math.Log10(tolPx)))
+	if prec < 0 || math.IsNaN(tolPx) {
+		return 0
+	} else if prec > 6 {
+		return 6
+	}
+	return prec
+}
+
+// pixel maps a data point to pixel coordinates of the plot area.
+// Empty ranges are mapped to the middle of the plot area.
+func (s *SVG) pixel(x, y float64) (px, py float64) {
+	px, py = s.Width/2, s.Height/2
+	if s.XMax > s.XMin {
+		px = (x - s.XMin) / (s.XMax - s.XMin) * s.Width
+	}
+	if s.YMax > s.YMin {
+		py = (s.YMax - y) / (s.YMax - s.YMin) * s.Height
+	}
+	return px, py
+}
+
+// svgPath writes the data of a path element in pixel
+// coordinates of the plot area, which is offset by left and top.
+type svgPath struct {
+	svg       *SVG
+	w         *bufio.Writer
+	left, top float64
+	prec      int
+	buf       []byte
+	started   bool
+}
+
+// command writes the path command cmd followed by the coordinates of
+// data points given as x, y pairs. An empty cmd repeats the last one.
+func (p *svgPath) command(cmd string, xy ...float64) {
+	p.buf = p.buf[:0]
+	if p.started {
+		p.buf = append(p.buf, ' ')
+	}
+	p.buf = append(p.buf, cmd...)
+	for i := 0; i < len(xy); i += 2 {
+		if i > 0 {
+			p.buf = append(p.buf, ' ')
+		}
+		px, py := p.svg.pixel(xy[i], xy[i+1])
+		p.buf = strconv.AppendFloat(p.buf, p.left+px, 'f', p.prec, 64)
+		p.buf = append(p.buf, ',')
+		p.buf = strconv.AppendFloat(p.buf, p.top+py, 'f', p.prec, 64)
+	}
+	p.w.Write(p.buf)
+	p.started = true
+}
+
+// writeAxes draws a frame around the plot area with ticks and labels.
+func (s *SVG) writeAxes(w *bufio.Writer, left, top float64) {
+	fmt.Fprintf(w, `<g stroke="black" fill="none"><rect x="%g" y="%g" width="%g" height="%g"/>`, left, top, s.Width, s.Height)
+	xticks, yticks := niceTicks(s.XMin, s.XMax, 6), niceTicks(s.YMin, s.YMax, 6)
+	for _, t := range xticks {
+		px, _ := s.pixel(t, 0)
+		fmt.Fprintf(w, `<path d="M%.1f,%g v4"/>`, left+px, top+s.Height)
+	}
+	for _, t := range yticks {
+		_, py := s.pixel(0, t)
+		fmt.Fprintf(w, `<path d="M%g,%.1f h-4"/>`, left, top+py)
+	}
+	w.WriteString("</g>\n")
+	w.WriteString(`<g font-family="sans-serif" font-size="12" fill="black">`)
+	for _, t := range xticks {
+		px, _ := s.pixel(t, 0)
+		fmt.Fprintf(w, `<text x="%.1f" y="%g" text-anchor="middle">%s</text>`, left+px, top+s.Height+18, strconv.FormatFloat(t, 'g', 4, 64))
+	}
+	for _, t := range yticks {
+		_, py := s.pixel(0, t)
+		fmt.Fprintf(w, `<text x="%g" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`, left-6, top+py, strconv.FormatFloat(t, 'g', 4, 64))
+	}
+	w.WriteString("</g>\n")
+}
+
+// niceTicks returns at most n round numbers between min and max,
+// spaced by 1, 2 or 5 times a power of ten.
+func niceTicks(min, max float64, n int) []float64 {
+	if !(max > min) {
+		return []float64{min}
+	}
+	base := math.Pow(10, math.Floor(math.Log10((max-min)/float64(n))))
+	var step float64
+	for _, m := range []float64{1, 2, 5, 10} {
+		if step = base * m; (max-min)/step < float64(n) {
+			break
+		}
+	}
+	var ticks []float64
+	for k := math.Ceil(min / step); k*step <= max; k++ {
+		ticks = append(ticks, k*step)
+	}
+	return ticks
+}
@@ -0,0 +1,46 @@
+package decim
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSVG(t *testing.T) {
+	xy := &sliceXYer{x: []float64{0, 1, 2, 3, 4}, y: []float64{0, 1, math.NaN(), 1, 2}}
+	s := &SVG{Viewport: Viewport{XMin: 0, XMax: 4, YMin: 0, YMax: 2, Width: 400, Height: 200}}
+	var buf bytes.Buffer
+	if err := s.Write(&buf, xy); err != nil {
+		t.Fatal(err)
+	}
+	// Half a pixel tolerance needs a decimal.
+	if want := `d="M0.0,200.0 100.0,100.0 M300.0,100.0 400.0,0.0"`; !strings.Contains(buf.String(), want) {
+		t.Errorf("path not found in:\n%s", buf.String())
+	}
+	s.Axes, s.Tolerance = true, 0.1
+	buf.Reset()
+	if err := s.Write(&buf, xy, xy); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `viewBox="0 0 480 248"`) || strings.Count(out, "<path fill") != 2 || !strings.Contains(out, ">1.5</text>") {
+		t.Errorf("bad SVG with axes:\n%s", out)
+	}
+	if !strings.Contains(out, `d="M64,216 164,116`) {
+		t.Errorf("expected whole pixel coordinates for 10 pixel tolerance:\n%s", out)
+	}
+}
+
+func TestNiceTicks(t *testing.T) {
+	got := niceTicks(-0.3, 1.25, 6)
+	want := []float64{0, 0.5, 1}
+	if len(got) != len(want) {
+		t.Fatalf("got ticks %v, want %v", got, want)
+	}
+	for i := range got {
+		if !sameFloat(got[i], want[i]) {
+			t.Errorf("got ticks %v, want %v", got, want)
+		}
+	}
+}
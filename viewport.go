@@ -0,0 +1,86 @@
+package decim
+
+import (
+	"errors"
+	"math"
+)
+
+// Viewport describes the region of data visible in a plot and
+// the size in pixels of the area it is drawn on. It is used to derive
+// tolerances which keep decimation error invisible.
+type Viewport struct {
+	XMin, XMax, YMin, YMax float64
+	// Width and Height of the plot area in pixels.
+	Width, Height float64
+}
+
+// NewViewport returns a Viewport spanning the x and y range of xyer
+// drawn on a plot area of width×height pixels.
+func NewViewport(xyer XYer, width, height float64) Viewport {
+	v := Viewport{
+		XMin: math.Inf(1), XMax: math.Inf(-1),
+		YMin: math.Inf(1), YMax: math.Inf(-1),
+		Width: width, Height: height,
+	}
+	for i := 0; i < xyer.Len(); i++ {
+		x, y := xyer.XY(i)
+		v.XMin, v.XMax = math.Min(v.XMin, x), math.Max(v.XMax, x)
+		v.YMin, v.YMax = math.Min(v.YMin, y), math.Max(v.YMax, y)
+	}
+	return v
+}
+
+// SetPhysicalSize sets the plot area size in pixels from its physical
+// width and height in inches when printed at dpi dots per inch.
+func (v *Viewport) SetPhysicalSize(width, height, dpi float64) {
+	v.Width, v.Height = width*dpi, height*dpi
+}
+
+// Tolerance returns the size of half a pixel in data units along each axis.
+func (v Viewport) Tolerance() (xtol, ytol float64) {
+	return (v.XMax - v.XMin) / v.Width / 2, (v.YMax - v.YMin) / v.Height / 2
+}
+
+// Decimate reduces xyer's points so that, when drawn on v, the
+// decimated curve is never more than half a pixel away from the original
+// one, which makes them indistinguishable. Distances are measured in
+// pixels along both axes, so steep parts of a curve keep fewer points
+// than a vertical tolerance alone would allow. Points are chosen with
+// the Ramer–Douglas–Peucker algorithm. x values must be increasing.
+func (v Viewport) Decimate(xyer XYer) (XYer, error) {
+	xtol, ytol := v.Tolerance()
+	if !(xtol >= 0) || !(ytol >= 0) || math.IsInf(xtol, 0) || math.IsInf(ytol, 0) {
+		return nil, errors.New("viewport must have a finite data range and positive size")
+	}
+	x, y, err := xyerSlices(xyer)
+	if err != nil {
+		return nil, err
+	}
+	// Pixel sizes along each axis. Empty ranges have any size.
+	xpx, ypx := 2*xtol, 2*ytol
+	if xpx == 0 {
+		xpx = 1
+	}
+	if ypx == 0 {
+		ypx = 1
+	}
+	keep := rdp(len(x), 0.5, func(i, first, last int) float64 {
+		return segmentDistance(
+			x[i]/xpx, y[i]/ypx,
+			x[first]/xpx, y[first]/ypx,
+			x[last]/xpx, y[last]/ypx,
+		)
+	})
+	return keptPoints(x, y, keep), nil
+}
+
+// segmentDistance returns the distance from point (x, y)
+// to the segment between (x0, y0) and (x1, y1).
+func segmentDistance(x, y, x0, y0, x1, y1 float64) float64 {
+	dx, dy := x1-x0, y1-y0
+	t := 0.0
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		t = math.Max(0, math.Min(1, ((x-x0)*dx+(y-y0)*dy)/l2))
+	}
+	return math.Hypot(x-x0-t*dx, y-y0-t*dy)
+}
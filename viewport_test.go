@@ -0,0 +1,55 @@
+package decim
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestViewport(t *testing.T) {
+	c := readTestCSV(t)
+	// 8cm wide figure at 300 DPI.
+	v := NewViewport(c, 0, 0)
+	v.SetPhysicalSize(8/2.54, 5/2.54, 300)
+	if math.Abs(v.Width-8/2.54*300) > 1e-9 {
+		t.Errorf("unexpected width %g", v.Width)
+	}
+	xtol, ytol := v.Tolerance()
+	if want := (v.XMax - v.XMin) / v.Width / 2; xtol != want {
+		t.Errorf("x tolerance %g, want %g", xtol, want)
+	}
+	if want := (v.YMax - v.YMin) / v.Height / 2; ytol != want {
+		t.Errorf("y tolerance %g, want %g", ytol, want)
+	}
+	d, err := v.Decimate(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() >= c.Len() {
+		t.Fatal("did not decimate succesfully")
+	}
+	dx := d.(*sliceXYer).x
+	for i := 0; i < c.Len(); i++ {
+		x, y := c.XY(i)
+		// Distance in pixels to the decimated segment spanning x.
+		j := sort.SearchFloat64s(dx, x)
+		if j == 0 {
+			j++
+		}
+		x0, y0 := d.XY(j - 1)
+		x1, y1 := d.XY(j)
+		xpx, ypx := 2*xtol, 2*ytol
+		if px := segmentDistance(x/xpx, y/ypx, x0/xpx, y0/ypx, x1/xpx, y1/ypx); px > 0.5+1e-6 {
+			t.Fatalf("point %d off by %g pixels", i, px)
+		}
+	}
+	// A narrower plot hides more points.
+	v.Width /= 10
+	narrow, err := v.Decimate(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if narrow.Len() >= d.Len() {
+		t.Errorf("narrower plot kept %d points, not fewer than %d", narrow.Len(), d.Len())
+	}
+}
@@ -0,0 +1,206 @@
+package decim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WAV sample formats.
+const (
+	wavPCM        = 1
+	wavFloat      = 3
+	wavExtensible = 0xfffe
+)
+
+// WAVFormat describes the samples of a WAV file.
+type WAVFormat struct {
+	SampleRate    int
+	NumChannels   int
+	BitsPerSample int
+	// Float is set for IEEE floating point samples. Otherwise samples are integer PCM.
+	Float bool
+}
+
+// WAVReader reads frames of a WAV file without loading it into memory.
+// Integer samples are scaled to [-1, 1) as floating point ones are.
+type WAVReader struct {
+	r      *bufio.Reader
+	format WAVFormat
+	// remaining is the number of data bytes left or -1 if unknown.
+	remaining int64
+	buf       []byte
+}
+
+// NewWAVReader reads the WAV header from r up to the start of sample data.
+// PCM of 8, 16, 24 and 32 bits, 32 and 64 bit floating point
+// and the extensible format holding either of them are supported.
+func NewWAVReader(r io.Reader) (*WAVReader, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	var riff [12]byte
+	if _, err := io.ReadFull(br, riff[:]); err != nil {
+		return nil, err
+	}
+	if string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+	w := &WAVReader{r: br}
+	var gotFormat bool
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(br, chunk[:]); err != nil {
+			return nil, fmt.Errorf("looking for WAV data: %w", err)
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[:4]) {
+		case "fmt ":
+			if size < 16 || size > 1<<10 {
+				return nil, errors.New("bad WAV format chunk")
+			}
+			b := make([]byte, size+size%2)
+			if _, err := io.ReadFull(br, b); err != nil {
+				return nil, err
+			}
+			if err := w.parseFormat(b[:size]); err != nil {
+				return nil, err
+			}
+			gotFormat = true
+		case "data":
+			if !gotFormat {
+				return nil, errors.New("WAV data before format chunk")
+			}
+			w.remaining = size
+			if size == math.MaxUint32 {
+				// Streamed files may not know their length.
+				w.remaining = -1
+			}
+			w.buf = make([]byte, w.format.NumChannels*w.format.BitsPerSample/8)
+			return w, nil
+		default:
+			// Chunks are padded to even size.
+			if _, err := br.Discard(int(size + size%2)); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (w *WAVReader) parseFormat(b []byte) error {
+	le := binary.LittleEndian
+	tag := le.Uint16(b)
+	w.format = WAVFormat{
+		NumChannels:   int(le.Uint16(b[2:])),
+		SampleRate:    int(le.Uint32(b[4:])),
+		BitsPerSample: int(le.Uint16(b[14:])),
+	}
+	if tag == wavExtensible {
+		// Sub format GUID starts with the format tag.
+		if len(b) < 26 {
+			return errors.New("bad extensible WAV format chunk")
+		}
+		tag = le.Uint16(b[24:])
+	}
+	f := &w.format
+	switch {
+	case f.NumChannels < 1 || f.SampleRate < 1:
+		return errors.New("bad WAV channel count or sample rate")
+	case tag == wavPCM && f.BitsPerSample%8 == 0 && f.BitsPerSample >= 8 && f.BitsPerSample <= 32:
+	case tag == wavFloat && (f.BitsPerSample == 32 || f.BitsPerSample == 64):
+		f.Float = true
+	default:
+		return fmt.Errorf("unsupported WAV format %d with %d bits per sample", tag, f.BitsPerSample)
+	}
+	return nil
+}
+
+// Format returns the format of samples.
+func (w *WAVReader) Format() WAVFormat { return w.format }
+
+// Read reads the next frame, one sample per channel, into dst.
+// It returns io.EOF when no frames remain.
+func (w *WAVReader) Read(dst []float64) error {
+	if w.remaining >= 0 && w.remaining < int64(len(w.buf)) {
+		return io.EOF
+	}
+	if _, err := io.ReadFull(w.r, w.buf); err != nil {
+		return err
+	}
+	if w.remaining > 0 {
+		w.remaining -= int64(len(w.buf))
+	}
+	size := w.format.BitsPerSample / 8
+	for c := range dst[:w.format.NumChannels] {
+		dst[c] = w.format.decode(w.buf[c*size : (c+1)*size])
+	}
+	return nil
+}
+
+func (f WAVFormat) decode(b []byte) float64 {
+	le := binary.LittleEndian
+	if f.Float {
+		if len(b) == 4 {
+			return float64(math.Float32frombits(le.Uint32(b)))
+		}
+		return math.Float64frombits(le.Uint64(b))
+	}
+	if len(b) == 1 {
+		// 8 bit samples are unsigned.
+		return (float64(b[0]) - 128) / 128
+	}
+	// Sign extend little endian integer of len(b) bytes.
+	var v uint32
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint32(b[i])
+	}
+	shift := uint(32 - 8*len(b))
+	return float64(int32(v<<shift)>>shift) / float64(uint32(1)<<(8*len(b)-1))
+}
+
+// WAV holds the samples of a WAV file per channel.
+type WAV struct {
+	WAVFormat
+	Samples [][]float64
+}
+
+// ReadWAV reads all samples of a WAV file.
+func ReadWAV(r io.Reader) (*WAV, error) {
+	wr, err := NewWAVReader(r)
+	if err != nil {
+		return nil, err
+	}
+	wav := &WAV{WAVFormat: wr.format, Samples: make([][]float64, wr.format.NumChannels)}
+	frame := make([]float64, wr.format.NumChannels)
+	for {
+		err := wr.Read(frame)
+		if errors.Is(err, io.EOF) {
+			return wav, nil
+		} else if err != nil {
+			return nil, err
+		}
+		for c, v := range frame {
+			wav.Samples[c] = append(wav.Samples[c], v)
+		}
+	}
+}
+
+// Channel returns channel c's samples against time in seconds.
+func (w *WAV) Channel(c int) XYer {
+	return sampledXYer{rate: float64(w.SampleRate), y: w.Samples[c]}
+}
+
+// sampledXYer holds uniformly sampled values.
+type sampledXYer struct {
+	rate float64
+	y    []float64
+}
+
+func (s sampledXYer) XY(i int) (x, y float64) {
+	return float64(i) / s.rate, s.y[i]
+}
+
+func (s sampledXYer) Len() int {
+	return len(s.y)
+}
@@ -0,0 +1,78 @@
+package decim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// wavFile builds a WAV file with a format chunk fmtChunk, an unknown chunk and data.
+func wavFile(fmtChunk, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+8+len(fmtChunk)+8+1+1+8+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(len(fmtChunk)))
+	b.Write(fmtChunk)
+	b.WriteString("LIST\x01\x00\x00\x00x\x00") // odd sized chunk with padding
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func wavFmt(tag uint16, channels, rate, bits int) []byte {
+	var b bytes.Buffer
+	blockAlign := channels * bits / 8
+	binary.Write(&b, binary.LittleEndian, []uint16{tag, uint16(channels)})
+	binary.Write(&b, binary.LittleEndian, []uint32{uint32(rate), uint32(rate * blockAlign)})
+	binary.Write(&b, binary.LittleEndian, []uint16{uint16(blockAlign), uint16(bits)})
+	if tag == wavExtensible {
+		binary.Write(&b, binary.LittleEndian, []uint16{22, uint16(bits)})
+		binary.Write(&b, binary.LittleEndian, uint32(4))
+		binary.Write(&b, binary.LittleEndian, uint16(wavPCM))
+		b.Write(make([]byte, 14))
+	}
+	return b.Bytes()
+}
+
+func TestWAV(t *testing.T) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, []int16{0, -32768, 16384, 32767})
+	for _, test := range []struct {
+		name     string
+		file     []byte
+		channels int
+		want     []float64 // first channel
+	}{
+		{"pcm16 stereo", wavFile(wavFmt(wavPCM, 2, 8000, 16), data.Bytes()), 2, []float64{0, 0.5}},
+		{"pcm8", wavFile(wavFmt(wavPCM, 1, 8000, 8), []byte{128, 0, 192}), 1, []float64{0, -1, 0.5}},
+		{"pcm24 extensible", wavFile(wavFmt(wavExtensible, 1, 8000, 24), []byte{0, 0, 0x80, 0, 0, 0x40}), 1, []float64{-1, 0.5}},
+	} {
+		wav, err := ReadWAV(bytes.NewReader(test.file))
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if len(wav.Samples) != test.channels || wav.SampleRate != 8000 {
+			t.Fatalf("%s: bad format %+v", test.name, wav.WAVFormat)
+		}
+		ch := wav.Channel(0)
+		if ch.Len() != len(test.want) {
+			t.Fatalf("%s: got %d samples, want %d", test.name, ch.Len(), len(test.want))
+		}
+		for i, want := range test.want {
+			if x, y := ch.XY(i); y != want || x != float64(i)/8000 {
+				t.Errorf("%s: sample %d got (%g, %g), want y %g", test.name, i, x, y, want)
+			}
+		}
+	}
+	data.Reset()
+	binary.Write(&data, binary.LittleEndian, []float32{0.25, -0.75})
+	wav, err := ReadWAV(bytes.NewReader(wavFile(wavFmt(wavFloat, 1, 44100, 32), data.Bytes())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wav.Float || wav.Samples[0][1] != -0.75 {
+		t.Errorf("bad float samples %v", wav.Samples)
+	}
+}